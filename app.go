@@ -3,12 +3,16 @@ package main
 import (
 	"context"
 	"fmt"
+
+	"github.com/jmoiron/sqlx"
 )
 
 // App struct
 type App struct {
 	ctx     context.Context
 	updater *Updater
+	actor   string      // Bearbeiter für das Änderungsprotokoll
+	history planHistory // Undo/Redo der laufenden Sitzung
 }
 
 // NewApp creates a new App application struct
@@ -31,23 +35,34 @@ func (a *App) Greet(name string) string {
 
 // PRODUKTE
 
+// productColumns sind die Spalten, die für ein Product aus products (Alias p) gelesen werden
+const productColumns = "p.id, p.name, p.multiline, p.portion_size, p.vegetarian, p.vegan, p.pork_free, p.archived, " +
+	"(SELECT COALESCE(MAX(pr.revision), 0) FROM product_revisions pr WHERE pr.product_id = p.id) AS revision"
+
 // GetProducts gibt alle Produkte zurück
 func (a *App) GetProducts() ([]Product, error) {
+	return a.FilterProducts(ProductFilter{})
+}
+
+// FilterProducts gibt die Produkte zurück, die zu Kategorie, Schlagworten und Ernährungsfiltern passen
+func (a *App) FilterProducts(filter ProductFilter) ([]Product, error) {
+	where, args := productFilterSQL(filter)
 	query := `
-		SELECT p.id, p.name, p.multiline
+		SELECT ` + productColumns + `
 		FROM products p
+		WHERE ` + where + `
 		ORDER BY p.name
 	`
 
 	var products []Product
-	err := db.Select(&products, query)
+	err := db.Select(&products, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get products: %w", err)
 	}
 
 	// Allergene und Zusatzstoffe für jedes Produkt laden
 	for i := range products {
-		if err := loadProductRelations(&products[i]); err != nil {
+		if err := loadProductRelations(db, &products[i]); err != nil {
 			return nil, err
 		}
 	}
@@ -57,18 +72,7 @@ func (a *App) GetProducts() ([]Product, error) {
 
 // GetProduct gibt ein einzelnes Produkt zurück
 func (a *App) GetProduct(id int) (*Product, error) {
-	var product Product
-	query := "SELECT id, name, multiline FROM products WHERE id = ?"
-	err := db.Get(&product, query, id)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get product: %w", err)
-	}
-
-	if err := loadProductRelations(&product); err != nil {
-		return nil, err
-	}
-
-	return &product, nil
+	return getProduct(db, id)
 }
 
 // CreateProduct erstellt ein neues Produkt
@@ -106,15 +110,32 @@ func (a *App) CreateProduct(name string, multiline bool, allergenIDs []string, a
 		}
 	}
 
+	// Erste Revision festhalten
+	if _, err := createProductRevision(tx, int(productID)); err != nil {
+		return nil, err
+	}
+
+	product, err := getProduct(tx, int(productID))
+	if err != nil {
+		return nil, err
+	}
+	if err := a.audit(tx, auditActionCreate, auditEntityProduct, product.ID, nil, nil, product); err != nil {
+		return nil, err
+	}
+
 	if err := tx.Commit(); err != nil {
 		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
-
-	return a.GetProduct(int(productID))
+	return product, nil
 }
 
 // UpdateProduct aktualisiert ein Produkt
 func (a *App) UpdateProduct(id int, name string, multiline bool, allergenIDs []string, additiveIDs []string) (*Product, error) {
+	before, err := a.GetProduct(id)
+	if err != nil {
+		return nil, err
+	}
+
 	tx, err := db.Beginx()
 	if err != nil {
 		return nil, fmt.Errorf("failed to begin transaction: %w", err)
@@ -154,22 +175,120 @@ func (a *App) UpdateProduct(id int, name string, multiline bool, allergenIDs []s
 		}
 	}
 
+	// Neue Revision anlegen; bestehende Planeinträge behalten ihre alte Revision
+	if _, err := createProductRevision(tx, id); err != nil {
+		return nil, err
+	}
+
+	after, err := a.auditProductUpdate(tx, before)
+	if err != nil {
+		return nil, err
+	}
+
 	if err := tx.Commit(); err != nil {
 		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
-
-	return a.GetProduct(id)
+	return after, nil
 }
 
-// DeleteProduct löscht ein Produkt
+// DeleteProduct löscht ein Produkt, sofern es in keinem Wochenplan und keiner Vorlagen- oder
+// Rotationswoche verwendet wird und keine Lagerbewegungen hat. Solche Produkte müssen
+// archiviert oder zusammengeführt werden.
 func (a *App) DeleteProduct(id int) error {
-	_, err := db.Exec("DELETE FROM products WHERE id = ?", id)
+	var used int
+	err := db.Get(&used, "SELECT COUNT(*) FROM plan_entries WHERE product_id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to check product usage: %w", err)
+	}
+	if used > 0 {
+		return fmt.Errorf("product is used in %d plan entries; archive or merge it instead", used)
+	}
+
+	var inTemplates int
+	err = db.Get(&inTemplates, "SELECT COUNT(*) FROM template_entries WHERE product_id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to check template usage: %w", err)
+	}
+	if inTemplates > 0 {
+		return fmt.Errorf("product is used in %d template entries; archive or merge it instead", inTemplates)
+	}
+
+	// Lagerbewegungen gehen mit dem Produkt nicht verloren
+	var movements int
+	err = db.Get(&movements, `
+		SELECT COUNT(*) FROM stock_movements sm
+		JOIN stock_items si ON si.id = sm.stock_item_id
+		WHERE si.product_id = ?
+	`, id)
+	if err != nil {
+		return fmt.Errorf("failed to check stock movements: %w", err)
+	}
+	if movements > 0 {
+		return fmt.Errorf("product has %d stock movements; archive or merge it instead", movements)
+	}
+
+	before, err := a.GetProduct(id)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("DELETE FROM products WHERE id = ?", id)
 	if err != nil {
 		return fmt.Errorf("failed to delete product: %w", err)
 	}
+	if err := a.audit(tx, auditActionDelete, auditEntityProduct, id, nil, before, nil); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
 	return nil
 }
 
+// SetProductDietFlags setzt die Ernährungsmerkmale eines Produkts
+func (a *App) SetProductDietFlags(id int, vegetarian bool, vegan bool, porkFree bool) (*Product, error) {
+	// Vegan schließt vegetarisch und schweinefleischfrei ein
+	if vegan {
+		vegetarian = true
+	}
+	if vegetarian {
+		porkFree = true
+	}
+
+	before, err := a.GetProduct(id)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("UPDATE products SET vegetarian = ?, vegan = ?, pork_free = ? WHERE id = ?", vegetarian, vegan, porkFree, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update diet flags: %w", err)
+	}
+
+	after, err := a.auditProductUpdate(tx, before)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return after, nil
+}
+
 // ALLERGENE & ZUSATZSTOFFE
 
 // GetAllergens gibt alle Allergene zurück
@@ -196,51 +315,21 @@ func (a *App) GetAdditives() ([]Additive, error) {
 
 // GetWeekPlan gibt einen Wochenplan zurück
 func (a *App) GetWeekPlan(year int, week int) (*WeekPlan, error) {
-	var plan WeekPlan
-	query := "SELECT id, year, week, created_at FROM week_plans WHERE year = ? AND week = ?"
-	err := db.Get(&plan, query, year, week)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get week plan: %w", err)
-	}
-
-	// Einträge laden
-	entries, err := a.loadPlanEntries(plan.ID)
-	if err != nil {
-		return nil, err
-	}
-	plan.Entries = entries
+	return a.GetWeekPlanAt(year, week, false)
+}
 
-	// Sondertage laden
-	specialDays, err := a.loadSpecialDays(plan.ID)
-	if err != nil {
+// GetWeekPlanAt gibt einen Wochenplan zurück; historical = Produkte im Stand
+// zum Planungszeitpunkt statt im aktuellen Stand
+func (a *App) GetWeekPlanAt(year int, week int, historical bool) (*WeekPlan, error) {
+	if err := validateWeek(year, week); err != nil {
 		return nil, err
 	}
-	plan.SpecialDays = specialDays
-
-	return &plan, nil
+	return a.loadWeekPlan(db, year, week, historical)
 }
 
 // CreateWeekPlan erstellt einen neuen Wochenplan
 func (a *App) CreateWeekPlan(year int, week int) (*WeekPlan, error) {
-	_, err := db.Exec("INSERT INTO week_plans (year, week) VALUES (?, ?)", year, week)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create week plan: %w", err)
-	}
-
-	return a.GetWeekPlan(year, week)
-}
-
-// CopyWeekPlan kopiert einen Wochenplan
-func (a *App) CopyWeekPlan(sourceYear int, sourceWeek int, targetYear int, targetWeek int) (*WeekPlan, error) {
-	// Erst Zielplan erstellen
-	targetPlan, err := a.CreateWeekPlan(targetYear, targetWeek)
-	if err != nil {
-		return nil, err
-	}
-
-	// Quellplan laden
-	sourcePlan, err := a.GetWeekPlan(sourceYear, sourceWeek)
-	if err != nil {
+	if err := validateWeek(year, week); err != nil {
 		return nil, err
 	}
 
@@ -250,52 +339,56 @@ func (a *App) CopyWeekPlan(sourceYear int, sourceWeek int, targetYear int, targe
 	}
 	defer tx.Rollback()
 
-	// Einträge kopieren
-	for _, entry := range sourcePlan.Entries {
-		_, err := tx.Exec(`
-			INSERT INTO plan_entries (week_plan_id, day, meal, slot, product_id, custom_text, group_label)
-			VALUES (?, ?, ?, ?, ?, ?, ?)
-		`, targetPlan.ID, entry.Day, entry.Meal, entry.Slot, entry.ProductID, entry.CustomText, entry.GroupLabel)
-		if err != nil {
-			return nil, fmt.Errorf("failed to copy plan entry: %w", err)
-		}
-	}
-
-	// Sondertage kopieren
-	for _, special := range sourcePlan.SpecialDays {
-		_, err := tx.Exec(`
-			INSERT INTO special_days (week_plan_id, day, type, label)
-			VALUES (?, ?, ?, ?)
-		`, targetPlan.ID, special.Day, special.Type, special.Label)
-		if err != nil {
-			return nil, fmt.Errorf("failed to copy special day: %w", err)
-		}
+	plan, err := a.createWeekPlan(tx, year, week)
+	if err != nil {
+		return nil, err
 	}
 
 	if err := tx.Commit(); err != nil {
 		return nil, fmt.Errorf("failed to commit transaction: %w", err)
 	}
+	return plan, nil
+}
 
-	return a.GetWeekPlan(targetYear, targetWeek)
+// CopyWeekPlan kopiert einen Wochenplan in eine noch nicht vorhandene Woche
+func (a *App) CopyWeekPlan(sourceYear int, sourceWeek int, targetYear int, targetWeek int) (*WeekPlan, error) {
+	result, err := a.CopyWeekPlanWithMode(sourceYear, sourceWeek, targetYear, targetWeek, copyConflictFail)
+	if err != nil {
+		return nil, err
+	}
+	return result.Plan, nil
 }
 
 // PLAN-EINTRÄGE
 
 // AddPlanEntry fügt einen Planeintrag hinzu
 func (a *App) AddPlanEntry(weekPlanID int, day int, meal string, productID *int, customText *string, groupLabel *string) (*PlanEntry, error) {
+	if err := validateOperatingDay(day); err != nil {
+		return nil, err
+	}
+	if err := ensurePlanEditable(weekPlanID); err != nil {
+		return nil, err
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
 	// Nächste Slot-Nummer ermitteln
 	var maxSlot int
-	err := db.Get(&maxSlot, "SELECT COALESCE(MAX(slot), -1) FROM plan_entries WHERE week_plan_id = ? AND day = ? AND meal = ?", weekPlanID, day, meal)
+	err = tx.Get(&maxSlot, "SELECT COALESCE(MAX(slot), -1) FROM plan_entries WHERE week_plan_id = ? AND day = ? AND meal = ?", weekPlanID, day, meal)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get max slot: %w", err)
 	}
 
 	slot := maxSlot + 1
 
-	result, err := db.Exec(`
-		INSERT INTO plan_entries (week_plan_id, day, meal, slot, product_id, custom_text, group_label)
-		VALUES (?, ?, ?, ?, ?, ?, ?)
-	`, weekPlanID, day, meal, slot, productID, customText, groupLabel)
+	result, err := tx.Exec(`
+		INSERT INTO plan_entries (week_plan_id, day, meal, slot, product_id, product_revision_id, custom_text, group_label)
+		VALUES (?, ?, ?, ?, ?, `+currentRevisionSQL+`, ?, ?)
+	`, weekPlanID, day, meal, slot, productID, productID, customText, groupLabel)
 	if err != nil {
 		return nil, fmt.Errorf("failed to add plan entry: %w", err)
 	}
@@ -305,52 +398,242 @@ func (a *App) AddPlanEntry(weekPlanID int, day int, meal string, productID *int,
 		return nil, fmt.Errorf("failed to get entry ID: %w", err)
 	}
 
-	return a.getPlanEntry(int(entryID))
+	entry, err := a.getPlanEntry(tx, int(entryID))
+	if err != nil {
+		return nil, err
+	}
+	if err := a.audit(tx, auditActionCreate, auditEntityPlanEntry, entry.ID, &entry.WeekPlanID, nil, entry); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	a.recordPlanChange(planChange{weekPlanID: entry.WeekPlanID, entries: []entryChange{{id: entry.ID, after: entry}}})
+	return entry, nil
 }
 
 // RemovePlanEntry entfernt einen Planeintrag
 func (a *App) RemovePlanEntry(id int) error {
-	_, err := db.Exec("DELETE FROM plan_entries WHERE id = ?", id)
+	before, err := a.getPlanEntry(db, id)
+	if err != nil {
+		return err
+	}
+	if err := ensurePlanEditable(before.WeekPlanID); err != nil {
+		return err
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("DELETE FROM plan_entries WHERE id = ?", id)
 	if err != nil {
 		return fmt.Errorf("failed to remove plan entry: %w", err)
 	}
+
+	// Nachfolgende Einträge rücken auf
+	err = renumberSlots(tx, "week_plan_id = ? AND day = ? AND meal = ?", before.WeekPlanID, before.Day, before.Meal)
+	if err != nil {
+		return err
+	}
+	if err := a.audit(tx, auditActionDelete, auditEntityPlanEntry, id, &before.WeekPlanID, before, nil); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	a.recordPlanChange(planChange{weekPlanID: before.WeekPlanID, entries: []entryChange{{id: id, before: before}}})
 	return nil
 }
 
 // UpdatePlanEntry aktualisiert einen Planeintrag
 func (a *App) UpdatePlanEntry(id int, productID *int, customText *string, groupLabel *string) (*PlanEntry, error) {
-	_, err := db.Exec(`
+	before, err := a.getPlanEntry(db, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := ensurePlanEditable(before.WeekPlanID); err != nil {
+		return nil, err
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Revision nur neu setzen, wenn ein anderes Produkt gewählt wurde
+	_, err = tx.Exec(`
 		UPDATE plan_entries 
-		SET product_id = ?, custom_text = ?, group_label = ?
+		SET product_revision_id = CASE WHEN product_id IS ? THEN product_revision_id ELSE `+currentRevisionSQL+` END,
+			product_id = ?, custom_text = ?, group_label = ?
 		WHERE id = ?
-	`, productID, customText, groupLabel, id)
+	`, productID, productID, productID, customText, groupLabel, id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update plan entry: %w", err)
 	}
 
-	return a.getPlanEntry(id)
+	entry, err := a.getPlanEntry(tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.audit(tx, auditActionUpdate, auditEntityPlanEntry, id, &entry.WeekPlanID, before, entry); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	a.recordPlanChange(planChange{weekPlanID: entry.WeekPlanID, entries: []entryChange{{id: id, before: before, after: entry}}})
+	return entry, nil
 }
 
 // SONDERTAGE
 
-// SetSpecialDay setzt einen Sondertag
+// SetSpecialDay setzt einen Sondertag für den ganzen Tag
 func (a *App) SetSpecialDay(weekPlanID int, day int, dtype string, label string) error {
-	_, err := db.Exec(`
-		INSERT OR REPLACE INTO special_days (week_plan_id, day, type, label)
-		VALUES (?, ?, ?, ?)
-	`, weekPlanID, day, dtype, label)
+	return a.SetSpecialDayWithScope(weekPlanID, day, dtype, label, nil, nil)
+}
+
+// SetSpecialDayWithScope setzt einen Sondertag, der nur einzelne Mahlzeiten (z.B. „schließt
+// mittags“ = nur Vesper) und/oder Gruppen betrifft; leere Listen bedeuten alle. Pro Tag kann es
+// mehrere Sondertage mit unterschiedlichem Bereich geben; bei gleichem Bereich wird der
+// bestehende aktualisiert.
+func (a *App) SetSpecialDayWithScope(weekPlanID int, day int, dtype string, label string, meals []string, groups []string) error {
+	if err := validateOperatingDay(day); err != nil {
+		return err
+	}
+	if err := validateSpecialDay(dtype, meals); err != nil {
+		return err
+	}
+	if err := ensurePlanEditable(weekPlanID); err != nil {
+		return err
+	}
+
+	mealScope, groupScope := specialDayScope(meals, groups)
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	before, err := getSpecialDay(tx, weekPlanID, day, mealScope, groupScope)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO special_days (week_plan_id, day, type, label, meals, groups)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(week_plan_id, day, meals, groups) DO UPDATE SET type = excluded.type, label = excluded.label
+	`, weekPlanID, day, dtype, label, mealScope, groupScope)
 	if err != nil {
 		return fmt.Errorf("failed to set special day: %w", err)
 	}
+
+	after, err := getSpecialDay(tx, weekPlanID, day, mealScope, groupScope)
+	if err != nil {
+		return err
+	}
+	if after == nil {
+		return fmt.Errorf("failed to set special day: not found after saving")
+	}
+	if before != nil {
+		err = a.audit(tx, auditActionUpdate, auditEntitySpecialDay, after.ID, &weekPlanID, before, after)
+	} else {
+		err = a.audit(tx, auditActionCreate, auditEntitySpecialDay, after.ID, &weekPlanID, nil, after)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	a.recordPlanChange(planChange{weekPlanID: weekPlanID, specials: []specialChange{newSpecialChange(before, after)}})
 	return nil
 }
 
-// RemoveSpecialDay entfernt einen Sondertag
+// RemoveSpecialDay entfernt alle Sondertage eines Tages
 func (a *App) RemoveSpecialDay(weekPlanID int, day int) error {
-	_, err := db.Exec("DELETE FROM special_days WHERE week_plan_id = ? AND day = ?", weekPlanID, day)
+	if err := ensurePlanEditable(weekPlanID); err != nil {
+		return err
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	specialDays, err := a.loadSpecialDays(tx, weekPlanID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM special_days WHERE week_plan_id = ? AND day = ?", weekPlanID, day)
 	if err != nil {
 		return fmt.Errorf("failed to remove special day: %w", err)
 	}
+
+	change := planChange{weekPlanID: weekPlanID}
+	for i := range specialDays {
+		before := &specialDays[i]
+		if before.Day != day {
+			continue
+		}
+		if err := a.audit(tx, auditActionDelete, auditEntitySpecialDay, before.ID, &weekPlanID, before, nil); err != nil {
+			return err
+		}
+		change.specials = append(change.specials, newSpecialChange(before, nil))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	if len(change.specials) > 0 {
+		a.recordPlanChange(change)
+	}
+	return nil
+}
+
+// RemoveSpecialDayWithScope entfernt den Sondertag eines Tages mit genau diesem Bereich
+func (a *App) RemoveSpecialDayWithScope(weekPlanID int, day int, meals []string, groups []string) error {
+	if err := ensurePlanEditable(weekPlanID); err != nil {
+		return err
+	}
+
+	mealScope, groupScope := specialDayScope(meals, groups)
+	before, err := getSpecialDay(db, weekPlanID, day, mealScope, groupScope)
+	if err != nil {
+		return err
+	}
+	if before == nil {
+		return nil
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM special_days WHERE id = ?", before.ID); err != nil {
+		return fmt.Errorf("failed to remove special day: %w", err)
+	}
+	if err := a.audit(tx, auditActionDelete, auditEntitySpecialDay, before.ID, &weekPlanID, before, nil); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	a.recordPlanChange(planChange{weekPlanID: weekPlanID, specials: []specialChange{newSpecialChange(before, nil)}})
 	return nil
 }
 
@@ -375,8 +658,76 @@ func (a *App) DownloadUpdate() (string, error) {
 
 // HILFSFUNKTIONEN
 
-// loadProductRelations lädt Allergene und Zusatzstoffe für ein Produkt
-func loadProductRelations(product *Product) error {
+// productFilterSQL baut die WHERE-Bedingung (Alias p) für einen ProductFilter
+func productFilterSQL(filter ProductFilter) (string, []interface{}) {
+	where := "1 = 1"
+	var args []interface{}
+
+	// Archivierte Produkte erscheinen nicht in Auswahllisten
+	if !filter.IncludeArchived {
+		where += " AND NOT p.archived"
+	}
+
+	// Kategorie inkl. aller Unterkategorien
+	if filter.CategoryID != nil {
+		where += `
+		AND p.id IN (
+			WITH RECURSIVE subtree(id) AS (
+				SELECT ?
+				UNION
+				SELECT c.id FROM categories c JOIN subtree s ON c.parent_id = s.id
+			)
+			SELECT pc.product_id FROM product_categories pc WHERE pc.category_id IN (SELECT id FROM subtree)
+		)`
+		args = append(args, *filter.CategoryID)
+	}
+
+	// Jedes Schlagwort muss vorhanden sein
+	for _, tagID := range filter.TagIDs {
+		where += `
+		AND EXISTS (SELECT 1 FROM product_tags pt WHERE pt.product_id = p.id AND pt.tag_id = ?)`
+		args = append(args, tagID)
+	}
+
+	// Frei von den angegebenen Allergenen
+	for _, allergenID := range filter.ExcludeAllergens {
+		where += `
+		AND NOT EXISTS (SELECT 1 FROM product_allergens pa WHERE pa.product_id = p.id AND pa.allergen_id = ?)`
+		args = append(args, allergenID)
+	}
+
+	// Ernährungsformen
+	if filter.Vegetarian {
+		where += " AND p.vegetarian"
+	}
+	if filter.Vegan {
+		where += " AND p.vegan"
+	}
+	if filter.PorkFree {
+		where += " AND p.pork_free"
+	}
+
+	return where, args
+}
+
+// getProduct lädt ein einzelnes Produkt über q (Datenbank oder laufende Transaktion)
+func getProduct(q sqlx.Queryer, id int) (*Product, error) {
+	var product Product
+	query := "SELECT " + productColumns + " FROM products p WHERE p.id = ?"
+	err := sqlx.Get(q, &product, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+
+	if err := loadProductRelations(q, &product); err != nil {
+		return nil, err
+	}
+
+	return &product, nil
+}
+
+// loadProductRelations lädt Allergene, Zusatzstoffe, Gruppen, Kategorien, Schlagworte und Nährwerte für ein Produkt
+func loadProductRelations(q sqlx.Queryer, product *Product) error {
 	// Allergene laden
 	allergenQuery := `
 		SELECT a.id, a.name, a.category
@@ -385,7 +736,7 @@ func loadProductRelations(product *Product) error {
 		WHERE pa.product_id = ?
 		ORDER BY a.id
 	`
-	err := db.Select(&product.Allergens, allergenQuery, product.ID)
+	err := sqlx.Select(q, &product.Allergens, allergenQuery, product.ID)
 	if err != nil {
 		return fmt.Errorf("failed to load allergens for product %d: %w", product.ID, err)
 	}
@@ -398,38 +749,108 @@ func loadProductRelations(product *Product) error {
 		WHERE pa.product_id = ?
 		ORDER BY a.id
 	`
-	err = db.Select(&product.Additives, additiveQuery, product.ID)
+	err = sqlx.Select(q, &product.Additives, additiveQuery, product.ID)
 	if err != nil {
 		return fmt.Errorf("failed to load additives for product %d: %w", product.ID, err)
 	}
 
-	return nil
+	// Lebensmittelgruppen laden
+	if err := loadProductFoodGroups(q, product); err != nil {
+		return err
+	}
+
+	// Kategorien und Schlagworte laden
+	if err := loadProductCategoriesAndTags(q, product); err != nil {
+		return err
+	}
+
+	// Nährwerte laden
+	return loadProductNutrition(q, product)
+}
+
+// loadWeekPlan lädt einen Wochenplan samt Einträgen, Sondertagen und Kalenderdaten über q
+func (a *App) loadWeekPlan(q sqlx.Queryer, year int, week int, historical bool) (*WeekPlan, error) {
+	var plan WeekPlan
+	query := "SELECT id, year, week, created_at, status, approved_by, approved_at, archived FROM week_plans WHERE year = ? AND week = ?"
+	err := sqlx.Get(q, &plan, query, year, week)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get week plan: %w", err)
+	}
+
+	// Einträge laden
+	entries, err := a.loadPlanEntriesAt(q, plan.ID, historical)
+	if err != nil {
+		return nil, err
+	}
+	plan.Entries = entries
+
+	// Sondertage laden
+	specialDays, err := a.loadSpecialDays(q, plan.ID)
+	if err != nil {
+		return nil, err
+	}
+	plan.SpecialDays = specialDays
+
+	// Kalenderdaten der Wochentage
+	days, err := planDays(plan.Year, plan.Week)
+	if err != nil {
+		return nil, err
+	}
+	plan.Days = days
+
+	return &plan, nil
 }
 
-// loadPlanEntries lädt Einträge für einen Wochenplan
-func (a *App) loadPlanEntries(weekPlanID int) ([]PlanEntry, error) {
+// createWeekPlan legt einen Wochenplan in der Transaktion an und protokolliert ihn
+func (a *App) createWeekPlan(tx *sqlx.Tx, year int, week int) (*WeekPlan, error) {
+	if _, err := tx.Exec("INSERT INTO week_plans (year, week) VALUES (?, ?)", year, week); err != nil {
+		return nil, fmt.Errorf("failed to create week plan: %w", err)
+	}
+
+	plan, err := a.loadWeekPlan(tx, year, week, false)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.audit(tx, auditActionCreate, auditEntityWeekPlan, plan.ID, &plan.ID, nil, plan); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// getWeekPlanByID lädt einen Wochenplan über seine ID
+func (a *App) getWeekPlanByID(q sqlx.Queryer, id int) (*WeekPlan, error) {
+	var plan WeekPlan
+	if err := sqlx.Get(q, &plan, "SELECT id, year, week, created_at FROM week_plans WHERE id = ?", id); err != nil {
+		return nil, fmt.Errorf("failed to get week plan: %w", err)
+	}
+	return a.loadWeekPlan(q, plan.Year, plan.Week, false)
+}
+
+// loadPlanEntries lädt Einträge für einen Wochenplan mit dem aktuellen Produktstand
+func (a *App) loadPlanEntries(q sqlx.Queryer, weekPlanID int) ([]PlanEntry, error) {
+	return a.loadPlanEntriesAt(q, weekPlanID, false)
+}
+
+// loadPlanEntriesAt lädt Einträge für einen Wochenplan; historical = Produktstand zum Planungszeitpunkt
+func (a *App) loadPlanEntriesAt(q sqlx.Queryer, weekPlanID int, historical bool) ([]PlanEntry, error) {
 	query := `
 		SELECT pe.id, pe.week_plan_id, pe.day, pe.meal, pe.slot, 
-			   pe.product_id, pe.custom_text, pe.group_label
+			   pe.product_id, pe.product_revision_id, pe.custom_text, pe.group_label
 		FROM plan_entries pe
 		WHERE pe.week_plan_id = ?
 		ORDER BY pe.day, pe.meal, pe.slot
 	`
 
 	var entries []PlanEntry
-	err := db.Select(&entries, query, weekPlanID)
+	err := sqlx.Select(q, &entries, query, weekPlanID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load plan entries: %w", err)
 	}
 
 	// Produkte laden
 	for i := range entries {
-		if entries[i].ProductID != nil {
-			product, err := a.GetProduct(*entries[i].ProductID)
-			if err != nil {
-				return nil, err
-			}
-			entries[i].Product = product
+		if err := a.attachEntryProduct(q, &entries[i], historical); err != nil {
+			return nil, err
 		}
 	}
 
@@ -437,41 +858,54 @@ func (a *App) loadPlanEntries(weekPlanID int) ([]PlanEntry, error) {
 }
 
 // loadSpecialDays lädt Sondertage für einen Wochenplan
-func (a *App) loadSpecialDays(weekPlanID int) ([]SpecialDay, error) {
+func (a *App) loadSpecialDays(q sqlx.Queryer, weekPlanID int) ([]SpecialDay, error) {
 	var specialDays []SpecialDay
 	query := `
-		SELECT id, week_plan_id, day, type, label
+		SELECT id, week_plan_id, day, type, label, meals, groups
 		FROM special_days
 		WHERE week_plan_id = ?
-		ORDER BY day
+		ORDER BY day, id
 	`
-	err := db.Select(&specialDays, query, weekPlanID)
+	err := sqlx.Select(q, &specialDays, query, weekPlanID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load special days: %w", err)
 	}
 	return specialDays, nil
 }
 
+// getSpecialDay lädt den Sondertag eines Tages mit genau diesem Bereich (nil, wenn keiner gesetzt ist)
+func getSpecialDay(q sqlx.Queryer, weekPlanID int, day int, meals scopeList, groups scopeList) (*SpecialDay, error) {
+	var specialDays []SpecialDay
+	err := sqlx.Select(q, &specialDays, `
+		SELECT id, week_plan_id, day, type, label, meals, groups
+		FROM special_days
+		WHERE week_plan_id = ? AND day = ? AND meals = ? AND groups = ?
+	`, weekPlanID, day, meals, groups)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get special day: %w", err)
+	}
+	if len(specialDays) == 0 {
+		return nil, nil
+	}
+	return &specialDays[0], nil
+}
+
 // getPlanEntry lädt einen einzelnen Planeintrag
-func (a *App) getPlanEntry(id int) (*PlanEntry, error) {
+func (a *App) getPlanEntry(q sqlx.Queryer, id int) (*PlanEntry, error) {
 	var entry PlanEntry
 	query := `
-		SELECT id, week_plan_id, day, meal, slot, product_id, custom_text, group_label
+		SELECT id, week_plan_id, day, meal, slot, product_id, product_revision_id, custom_text, group_label
 		FROM plan_entries
 		WHERE id = ?
 	`
-	err := db.Get(&entry, query, id)
+	err := sqlx.Get(q, &entry, query, id)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get plan entry: %w", err)
 	}
 
 	// Produkt laden wenn vorhanden
-	if entry.ProductID != nil {
-		product, err := a.GetProduct(*entry.ProductID)
-		if err != nil {
-			return nil, err
-		}
-		entry.Product = product
+	if err := a.attachEntryProduct(q, &entry, false); err != nil {
+		return nil, err
 	}
 
 	return &entry, nil
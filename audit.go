@@ -0,0 +1,381 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/user"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Entitäten im Änderungsprotokoll
+const (
+	auditEntityProduct    = "product"
+	auditEntityWeekPlan   = "week_plan"
+	auditEntityPlanEntry  = "plan_entry"
+	auditEntitySpecialDay = "special_day"
+)
+
+// Aktionen im Änderungsprotokoll
+const (
+	auditActionCreate   = "create"
+	auditActionUpdate   = "update"
+	auditActionDelete   = "delete"
+	auditActionCopy     = "copy"
+	auditActionUndo     = "undo"
+	auditActionRedo     = "redo"
+	auditActionGenerate = "generate"
+	auditActionStatus   = "status"
+	auditActionReopen   = "reopen"
+)
+
+// auditTimeLayout ist das Speicherformat für Zeitstempel (lokale Zeit, sortierbar)
+const auditTimeLayout = "2006-01-02 15:04:05"
+
+// PROTOKOLL
+
+// SetActor legt fest, wer die folgenden Änderungen vornimmt (z.B. Name der Küchenleitung)
+func (a *App) SetActor(name string) {
+	a.actor = strings.TrimSpace(name)
+}
+
+// GetActor gibt den aktuell eingetragenen Bearbeiter zurück
+func (a *App) GetActor() string {
+	return a.currentActor()
+}
+
+// GetAuditLog gibt Protokolleinträge passend zum Filter zurück (neueste zuerst)
+func (a *App) GetAuditLog(filter AuditFilter) ([]AuditEntry, error) {
+	where, args, err := auditFilterSQL(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT id, created_at, actor, action, entity, entity_id, week_plan_id, before, after, diff
+		FROM audit_log
+		WHERE ` + where + `
+		ORDER BY created_at DESC, id DESC
+	`
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+
+	entries := []AuditEntry{}
+	if err := db.Select(&entries, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to get audit log: %w", err)
+	}
+	return entries, nil
+}
+
+// ExportAuditCSV exportiert das gefilterte Protokoll als CSV (Semikolon, für Excel)
+func (a *App) ExportAuditCSV(filter AuditFilter, outputPath string) error {
+	entries, err := a.GetAuditLog(filter)
+	if err != nil {
+		return err
+	}
+
+	f, w, err := newExcelCSV(outputPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := w.Write([]string{"Zeitpunkt", "Bearbeiter", "Aktion", "Objekt", "ID", "Wochenplan", "Änderungen"}); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+	for _, e := range entries {
+		weekPlan := ""
+		if e.WeekPlanID != nil {
+			weekPlan = fmt.Sprint(*e.WeekPlanID)
+		}
+		record := []string{
+			e.CreatedAt.Format("02.01.2006 15:04:05"),
+			e.Actor,
+			e.Action,
+			e.Entity,
+			fmt.Sprint(e.EntityID),
+			weekPlan,
+			formatAuditDiff(e.Diff),
+		}
+		if err := w.Write(record); err != nil {
+			return fmt.Errorf("failed to write CSV record: %w", err)
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("failed to write CSV file: %w", err)
+	}
+	return f.Close()
+}
+
+// ExportAuditPDF exportiert das gefilterte Protokoll als PDF-Bericht (Hochformat A4)
+func (a *App) ExportAuditPDF(filter AuditFilter, outputPath string) error {
+	entries, err := a.GetAuditLog(filter)
+	if err != nil {
+		return err
+	}
+
+	pdf := newReportPDF("P")
+	pdf.AddPage()
+
+	pageW, _ := pdf.GetPageSize()
+	marginX := 10.0
+	usableW := pageW - 2*marginX
+
+	pdf.SetFont("DejaVu", "B", 14)
+	pdf.CellFormat(usableW, 8, "Änderungsprotokoll Speiseplan", "", 1, "L", false, 0, "")
+	pdf.SetFont("DejaVu", "", 9)
+	pdf.CellFormat(usableW, 5, describeAuditFilter(filter), "", 1, "L", false, 0, "")
+	pdf.Ln(3)
+
+	widths := []float64{32, 30, 18, 28, usableW - 108}
+	headers := []string{"Zeitpunkt", "Bearbeiter", "Aktion", "Objekt", "Änderungen"}
+	pdf.SetFont("DejaVu", "B", 8)
+	pdf.SetFillColor(220, 220, 220)
+	for i, h := range headers {
+		pdf.CellFormat(widths[i], 6, h, "1", 0, "L", true, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("DejaVu", "", 7)
+	for _, e := range entries {
+		diff := formatAuditDiff(e.Diff)
+		lines := pdf.SplitText(diff, widths[4]-2)
+		if len(lines) == 0 {
+			lines = []string{""}
+		}
+		rowH := 3.5 * float64(len(lines))
+		if rowH < 5 {
+			rowH = 5
+		}
+
+		// Seitenumbruch vor der Zeile, damit sie nicht zerrissen wird
+		_, pageH := pdf.GetPageSize()
+		if pdf.GetY()+rowH > pageH-15 {
+			pdf.AddPage()
+		}
+
+		x, y := pdf.GetXY()
+		cells := []string{
+			e.CreatedAt.Format("02.01.2006 15:04"),
+			e.Actor,
+			e.Action,
+			fmt.Sprintf("%s #%d", e.Entity, e.EntityID),
+		}
+		for i, text := range cells {
+			pdf.SetXY(x, y)
+			pdf.CellFormat(widths[i], rowH, text, "1", 0, "L", false, 0, "")
+			x += widths[i]
+		}
+		pdf.SetXY(x, y)
+		pdf.MultiCell(widths[4], 3.5, strings.Join(lines, "\n"), "", "L", false)
+		pdf.Rect(x, y, widths[4], rowH, "D")
+		pdf.SetXY(marginX, y+rowH)
+	}
+
+	if len(entries) == 0 {
+		pdf.CellFormat(usableW, 6, "Keine Einträge im gewählten Zeitraum.", "", 1, "L", false, 0, "")
+	}
+
+	return pdf.OutputFileAndClose(outputPath)
+}
+
+// HILFSFUNKTIONEN
+
+// audit schreibt einen Protokolleintrag in der Transaktion der Änderung. Schlägt das
+// Protokollieren fehl, wird die Änderung nicht gespeichert.
+func (a *App) audit(tx *sqlx.Tx, action, entity string, entityID int, weekPlanID *int, before, after interface{}) error {
+	beforeJSON, beforeMap := auditSnapshot(before)
+	afterJSON, afterMap := auditSnapshot(after)
+
+	diff := map[string]AuditChange{}
+	keys := map[string]bool{}
+	for k := range beforeMap {
+		keys[k] = true
+	}
+	for k := range afterMap {
+		keys[k] = true
+	}
+	for k := range keys {
+		if !reflect.DeepEqual(beforeMap[k], afterMap[k]) {
+			diff[k] = AuditChange{Before: beforeMap[k], After: afterMap[k]}
+		}
+	}
+
+	// Updates ohne tatsächliche Änderung nicht protokollieren
+	if action == auditActionUpdate && len(diff) == 0 {
+		return nil
+	}
+
+	diffJSON, err := json.Marshal(diff)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit diff for %s %d: %w", entity, entityID, err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO audit_log (created_at, actor, action, entity, entity_id, week_plan_id, before, after, diff)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, time.Now().Format(auditTimeLayout), a.currentActor(), action, entity, entityID, weekPlanID,
+		beforeJSON, afterJSON, string(diffJSON))
+	if err != nil {
+		return fmt.Errorf("failed to write audit entry for %s %d: %w", entity, entityID, err)
+	}
+	return nil
+}
+
+// auditProductUpdate lädt den neuen Stand eines Produkts in der Transaktion und protokolliert die Änderung
+func (a *App) auditProductUpdate(tx *sqlx.Tx, before *Product) (*Product, error) {
+	after, err := getProduct(tx, before.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.audit(tx, auditActionUpdate, auditEntityProduct, after.ID, nil, before, after); err != nil {
+		return nil, err
+	}
+	return after, nil
+}
+
+// auditSnapshot serialisiert einen Zustand als JSON und als Feld-Map für den Vergleich
+func auditSnapshot(v interface{}) (*string, map[string]interface{}) {
+	if v == nil || (reflect.ValueOf(v).Kind() == reflect.Ptr && reflect.ValueOf(v).IsNil()) {
+		return nil, map[string]interface{}{}
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, map[string]interface{}{}
+	}
+	s := string(data)
+
+	fields := map[string]interface{}{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return &s, map[string]interface{}{"value": v}
+	}
+	return &s, fields
+}
+
+// currentActor gibt den eingetragenen Bearbeiter oder den Benutzer des Betriebssystems zurück
+func (a *App) currentActor() string {
+	if a.actor != "" {
+		return a.actor
+	}
+	if u, err := user.Current(); err == nil {
+		if u.Name != "" {
+			return u.Name
+		}
+		return u.Username
+	}
+	return "unbekannt"
+}
+
+// auditFilterSQL baut die WHERE-Bedingung für einen AuditFilter
+func auditFilterSQL(filter AuditFilter) (string, []interface{}, error) {
+	where := "1 = 1"
+	var args []interface{}
+
+	if filter.Entity != "" {
+		where += " AND entity = ?"
+		args = append(args, filter.Entity)
+	}
+	if filter.EntityID != nil {
+		where += " AND entity_id = ?"
+		args = append(args, *filter.EntityID)
+	}
+	if filter.WeekPlanID != nil {
+		where += " AND week_plan_id = ?"
+		args = append(args, *filter.WeekPlanID)
+	}
+	if filter.Actor != "" {
+		where += " AND actor = ?"
+		args = append(args, filter.Actor)
+	}
+	if filter.From != "" {
+		from, err := time.ParseInLocation("2006-01-02", filter.From, time.Local)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid from date %q: %w", filter.From, err)
+		}
+		where += " AND created_at >= ?"
+		args = append(args, from.Format(auditTimeLayout))
+	}
+	if filter.To != "" {
+		to, err := time.ParseInLocation("2006-01-02", filter.To, time.Local)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid to date %q: %w", filter.To, err)
+		}
+		// Bis-Datum einschließlich
+		where += " AND created_at < ?"
+		args = append(args, to.AddDate(0, 0, 1).Format(auditTimeLayout))
+	}
+
+	return where, args, nil
+}
+
+// formatAuditDiff macht aus dem gespeicherten Diff eine lesbare Zeile je Feld
+func formatAuditDiff(diff string) string {
+	changes := map[string]AuditChange{}
+	if err := json.Unmarshal([]byte(diff), &changes); err != nil {
+		return diff
+	}
+
+	keys := make([]string, 0, len(changes))
+	for k := range changes {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var lines []string
+	for _, k := range keys {
+		c := changes[k]
+		lines = append(lines, fmt.Sprintf("%s: %s → %s", k, formatAuditValue(c.Before), formatAuditValue(c.After)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatAuditValue gibt einen JSON-Wert kompakt aus
+func formatAuditValue(v interface{}) string {
+	if v == nil {
+		return "–"
+	}
+	if s, ok := v.(string); ok {
+		return s
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// describeAuditFilter beschreibt den Filter für die Berichtsüberschrift
+func describeAuditFilter(filter AuditFilter) string {
+	var parts []string
+	if filter.From != "" || filter.To != "" {
+		parts = append(parts, fmt.Sprintf("Zeitraum: %s – %s", orDash(filter.From), orDash(filter.To)))
+	}
+	if filter.Entity != "" {
+		parts = append(parts, "Objekt: "+filter.Entity)
+	}
+	if filter.EntityID != nil {
+		parts = append(parts, fmt.Sprintf("ID: %d", *filter.EntityID))
+	}
+	if filter.Actor != "" {
+		parts = append(parts, "Bearbeiter: "+filter.Actor)
+	}
+	if len(parts) == 0 {
+		return "Alle Einträge"
+	}
+	return strings.Join(parts, "  |  ")
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "…"
+	}
+	return s
+}
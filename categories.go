@@ -0,0 +1,357 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// KATEGORIEN
+
+// GetCategories gibt alle Kategorien als flache Liste zurück
+func (a *App) GetCategories() ([]Category, error) {
+	var categories []Category
+	err := db.Select(&categories, "SELECT id, name, parent_id, color, sort_order FROM categories ORDER BY sort_order, name")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get categories: %w", err)
+	}
+	return categories, nil
+}
+
+// GetCategoryTree gibt die Kategorien als Baum zurück (Wurzeln mit Children)
+func (a *App) GetCategoryTree() ([]Category, error) {
+	categories, err := a.GetCategories()
+	if err != nil {
+		return nil, err
+	}
+
+	children := map[int][]Category{}
+	var roots []Category
+	for _, c := range categories {
+		if c.ParentID == nil {
+			roots = append(roots, c)
+		} else {
+			children[*c.ParentID] = append(children[*c.ParentID], c)
+		}
+	}
+
+	var attach func(c *Category)
+	attach = func(c *Category) {
+		c.Children = children[c.ID]
+		for i := range c.Children {
+			attach(&c.Children[i])
+		}
+	}
+	for i := range roots {
+		attach(&roots[i])
+	}
+
+	return roots, nil
+}
+
+// CreateCategory erstellt eine neue Kategorie
+func (a *App) CreateCategory(name string, parentID *int, color *string) (*Category, error) {
+	if err := validateCategoryColor(color); err != nil {
+		return nil, err
+	}
+
+	result, err := db.Exec(`
+		INSERT INTO categories (name, parent_id, color, sort_order)
+		VALUES (?, ?, ?, (SELECT COALESCE(MAX(sort_order), -1) + 1 FROM categories))
+	`, strings.TrimSpace(name), parentID, color)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create category: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get category ID: %w", err)
+	}
+
+	return getCategory(int(id))
+}
+
+// UpdateCategory aktualisiert Name, Elternkategorie und Farbe einer Kategorie
+func (a *App) UpdateCategory(id int, name string, parentID *int, color *string) (*Category, error) {
+	if err := validateCategoryColor(color); err != nil {
+		return nil, err
+	}
+
+	// Zyklen verhindern: neue Elternkategorie darf nicht im eigenen Teilbaum liegen
+	if parentID != nil {
+		var inSubtree int
+		err := db.Get(&inSubtree, `
+			WITH RECURSIVE subtree(id) AS (
+				SELECT ?
+				UNION
+				SELECT c.id FROM categories c JOIN subtree s ON c.parent_id = s.id
+			)
+			SELECT COUNT(*) FROM subtree WHERE id = ?
+		`, id, *parentID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check category hierarchy: %w", err)
+		}
+		if inSubtree > 0 {
+			return nil, fmt.Errorf("category cannot be moved below itself")
+		}
+	}
+
+	_, err := db.Exec("UPDATE categories SET name = ?, parent_id = ?, color = ? WHERE id = ?",
+		strings.TrimSpace(name), parentID, color, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update category: %w", err)
+	}
+
+	return getCategory(id)
+}
+
+// DeleteCategory löscht eine Kategorie; Unterkategorien rücken eine Ebene nach oben
+func (a *App) DeleteCategory(id int) error {
+	tx, err := db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		UPDATE categories
+		SET parent_id = (SELECT parent_id FROM categories WHERE id = ?)
+		WHERE parent_id = ?
+	`, id, id)
+	if err != nil {
+		return fmt.Errorf("failed to reparent subcategories: %w", err)
+	}
+
+	_, err = tx.Exec("DELETE FROM categories WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete category: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// SetProductCategories ordnet einem Produkt Kategorien zu
+func (a *App) SetProductCategories(productID int, categoryIDs []int) (*Product, error) {
+	before, err := a.GetProduct(productID)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("DELETE FROM product_categories WHERE product_id = ?", productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete old category links: %w", err)
+	}
+
+	for _, categoryID := range categoryIDs {
+		_, err := tx.Exec("INSERT INTO product_categories (product_id, category_id) VALUES (?, ?)", productID, categoryID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to link category: %w", err)
+		}
+	}
+
+	after, err := a.auditProductUpdate(tx, before)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return after, nil
+}
+
+// SCHLAGWORTE
+
+// GetTags gibt alle Schlagworte zurück
+func (a *App) GetTags() ([]Tag, error) {
+	var tags []Tag
+	err := db.Select(&tags, "SELECT id, name FROM tags ORDER BY name")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tags: %w", err)
+	}
+	return tags, nil
+}
+
+// RenameTag benennt ein Schlagwort um
+func (a *App) RenameTag(id int, name string) error {
+	_, err := db.Exec("UPDATE tags SET name = ? WHERE id = ?", strings.TrimSpace(name), id)
+	if err != nil {
+		return fmt.Errorf("failed to rename tag: %w", err)
+	}
+	return nil
+}
+
+// DeleteTag löscht ein Schlagwort und entfernt es von allen Produkten
+func (a *App) DeleteTag(id int) error {
+	_, err := db.Exec("DELETE FROM tags WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete tag: %w", err)
+	}
+	return nil
+}
+
+// SetProductTags setzt die Schlagworte eines Produkts; unbekannte Namen werden angelegt
+func (a *App) SetProductTags(productID int, tagNames []string) (*Product, error) {
+	before, err := a.GetProduct(productID)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("DELETE FROM product_tags WHERE product_id = ?", productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete old tag links: %w", err)
+	}
+
+	for _, name := range tagNames {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		_, err := tx.Exec("INSERT OR IGNORE INTO tags (name) VALUES (?)", name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create tag: %w", err)
+		}
+
+		var tagID int
+		if err := tx.Get(&tagID, "SELECT id FROM tags WHERE name = ?", name); err != nil {
+			return nil, fmt.Errorf("failed to get tag ID: %w", err)
+		}
+
+		_, err = tx.Exec("INSERT OR IGNORE INTO product_tags (product_id, tag_id) VALUES (?, ?)", productID, tagID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to link tag: %w", err)
+		}
+	}
+
+	after, err := a.auditProductUpdate(tx, before)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return after, nil
+}
+
+// HILFSFUNKTIONEN
+
+// getCategory lädt eine einzelne Kategorie
+func getCategory(id int) (*Category, error) {
+	var category Category
+	err := db.Get(&category, "SELECT id, name, parent_id, color, sort_order FROM categories WHERE id = ?", id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get category: %w", err)
+	}
+	return &category, nil
+}
+
+// loadProductCategoriesAndTags lädt Kategorien und Schlagworte eines Produkts
+func loadProductCategoriesAndTags(q sqlx.Queryer, product *Product) error {
+	categoryQuery := `
+		SELECT c.id, c.name, c.parent_id, c.color, c.sort_order
+		FROM categories c
+		JOIN product_categories pc ON c.id = pc.category_id
+		WHERE pc.product_id = ?
+		ORDER BY c.sort_order, c.name
+	`
+	err := sqlx.Select(q, &product.Categories, categoryQuery, product.ID)
+	if err != nil {
+		return fmt.Errorf("failed to load categories for product %d: %w", product.ID, err)
+	}
+
+	tagQuery := `
+		SELECT t.id, t.name
+		FROM tags t
+		JOIN product_tags pt ON t.id = pt.tag_id
+		WHERE pt.product_id = ?
+		ORDER BY t.name
+	`
+	err = sqlx.Select(q, &product.Tags, tagQuery, product.ID)
+	if err != nil {
+		return fmt.Errorf("failed to load tags for product %d: %w", product.ID, err)
+	}
+
+	return nil
+}
+
+// validateCategoryColor prüft, ob eine Farbe im Format #RRGGBB vorliegt
+func validateCategoryColor(color *string) error {
+	if color == nil || *color == "" {
+		return nil
+	}
+	if _, _, _, ok := parseHexColor(*color); !ok {
+		return fmt.Errorf("invalid color %q, expected #RRGGBB", *color)
+	}
+	return nil
+}
+
+// parseHexColor zerlegt eine Farbe im Format #RRGGBB in ihre Komponenten
+func parseHexColor(color string) (int, int, int, bool) {
+	if len(color) != 7 || color[0] != '#' {
+		return 0, 0, 0, false
+	}
+	v, err := strconv.ParseUint(color[1:], 16, 32)
+	if err != nil {
+		return 0, 0, 0, false
+	}
+	return int(v >> 16 & 0xFF), int(v >> 8 & 0xFF), int(v & 0xFF), true
+}
+
+// categoryColorResolver liefert die Farbe einer Kategorie; ohne eigene Farbe
+// wird die der nächsten Elternkategorie verwendet
+type categoryColorResolver map[int]Category
+
+func newCategoryColorResolver() (categoryColorResolver, error) {
+	var categories []Category
+	err := db.Select(&categories, "SELECT id, name, parent_id, color, sort_order FROM categories")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load categories: %w", err)
+	}
+
+	resolver := categoryColorResolver{}
+	for _, c := range categories {
+		resolver[c.ID] = c
+	}
+	return resolver, nil
+}
+
+// productColor gibt Farbe und Kategorie der ersten farbigen Kategorie eines Produkts zurück
+func (r categoryColorResolver) productColor(product *Product) (string, *Category) {
+	for _, pc := range product.Categories {
+		seen := map[int]bool{}
+		for id := pc.ID; !seen[id]; {
+			seen[id] = true
+			c, ok := r[id]
+			if !ok {
+				break
+			}
+			if c.Color != nil && *c.Color != "" {
+				return *c.Color, &c
+			}
+			if c.ParentID == nil {
+				break
+			}
+			id = *c.ParentID
+		}
+	}
+	return "", nil
+}
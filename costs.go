@@ -0,0 +1,443 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"speiseplan/isoweek"
+)
+
+// Früherer Einstellungsschlüssel des Verpflegungsbudgets; wird in daily_budgets übernommen
+const settingDailyBudget = "budget_per_child_day"
+
+// monthNames sind die deutschen Monatsnamen
+var monthNames = []string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"}
+
+// PREISE
+
+// GetProductPrices gibt die Preisgeschichte eines Produkts zurück, älteste zuerst.
+// Ein Preis gilt ab valid_from bis zum Tag vor dem nächsten Preis.
+func (a *App) GetProductPrices(productID int) ([]ProductPrice, error) {
+	prices, err := loadProductPrices()
+	if err != nil {
+		return nil, err
+	}
+	if list := prices[productID]; list != nil {
+		return list, nil
+	}
+	return []ProductPrice{}, nil
+}
+
+// SetProductPrice setzt den Preis eines Produkts (Euro je kg) ab einem Stichtag (YYYY-MM-DD).
+// Ein bestehender Preis zum selben Stichtag wird ersetzt; ältere Preise bleiben für
+// vergangene Wochen erhalten.
+func (a *App) SetProductPrice(productID int, pricePerKg float64, validFrom string) ([]ProductPrice, error) {
+	if pricePerKg < 0 {
+		return nil, fmt.Errorf("price must not be negative")
+	}
+	from, err := isoweek.ParseDate(validFrom)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := a.GetProduct(productID); err != nil {
+		return nil, err
+	}
+
+	_, err = db.Exec(`
+		INSERT INTO product_prices (product_id, price_per_kg, valid_from) VALUES (?, ?, ?)
+		ON CONFLICT(product_id, valid_from) DO UPDATE SET price_per_kg = excluded.price_per_kg
+	`, productID, pricePerKg, isoweek.FormatDate(from))
+	if err != nil {
+		return nil, fmt.Errorf("failed to set product price: %w", err)
+	}
+	return a.GetProductPrices(productID)
+}
+
+// DeleteProductPrice löscht einen Preis; der vorherige gilt dann entsprechend länger
+func (a *App) DeleteProductPrice(id int) error {
+	if _, err := db.Exec("DELETE FROM product_prices WHERE id = ?", id); err != nil {
+		return fmt.Errorf("failed to delete product price: %w", err)
+	}
+	return nil
+}
+
+// BUDGET
+
+// GetDailyBudgets gibt die Budgetgeschichte je Kind und Verpflegungstag zurück, älteste zuerst.
+// Ein Budget gilt ab valid_from bis zum Tag vor dem nächsten Budget.
+func (a *App) GetDailyBudgets() ([]DailyBudget, error) {
+	return loadDailyBudgets()
+}
+
+// SetDailyBudget legt das Budget je Kind und Verpflegungstag in Euro ab einem Stichtag
+// (YYYY-MM-DD) fest. Ein bestehendes Budget zum selben Stichtag wird ersetzt; ältere
+// Budgets bleiben für vergangene Wochen erhalten.
+func (a *App) SetDailyBudget(amount float64, validFrom string) ([]DailyBudget, error) {
+	if amount < 0 {
+		return nil, fmt.Errorf("budget must not be negative")
+	}
+	from, err := isoweek.ParseDate(validFrom)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = db.Exec(`
+		INSERT INTO daily_budgets (amount, valid_from) VALUES (?, ?)
+		ON CONFLICT(valid_from) DO UPDATE SET amount = excluded.amount
+	`, amount, isoweek.FormatDate(from))
+	if err != nil {
+		return nil, fmt.Errorf("failed to save budget: %w", err)
+	}
+	return loadDailyBudgets()
+}
+
+// DeleteDailyBudget löscht ein Budget; das vorherige gilt dann entsprechend länger
+func (a *App) DeleteDailyBudget(id int) error {
+	if _, err := db.Exec("DELETE FROM daily_budgets WHERE id = ?", id); err != nil {
+		return fmt.Errorf("failed to delete budget: %w", err)
+	}
+	return nil
+}
+
+// KOSTEN
+
+// GetWeekCosts berechnet die Kosten eines Wochenplans je Portion, Tag und Woche aus
+// Kinderzahlen, Portionsgrößen und den am jeweiligen Tag gültigen Preisen
+func (a *App) GetWeekCosts(weekPlanID int) (*WeekCostReport, error) {
+	plan, err := a.getWeekPlanByID(db, weekPlanID)
+	if err != nil {
+		return nil, err
+	}
+	budgets, err := loadDailyBudgets()
+	if err != nil {
+		return nil, err
+	}
+	prices, err := loadProductPrices()
+	if err != nil {
+		return nil, err
+	}
+	headcounts, err := weekHeadcounts(plan)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &WeekCostReport{
+		WeekPlanID:    plan.ID,
+		Year:          plan.Year,
+		Week:          plan.Week,
+		Budgets:       budgetsInRange(budgets, isoweek.FormatDate(isoweek.Day(plan.Year, plan.Week, 1)), isoweek.FormatDate(isoweek.Day(plan.Year, plan.Week, 7))),
+		MissingPrices: []string{},
+	}
+	report.Days, report.Lines = planCosts(plan, headcounts, prices, budgets, &report.MissingPrices)
+	for _, d := range report.Days {
+		report.Cost += d.Cost
+		report.Budget += d.Budget
+	}
+	sort.Strings(report.MissingPrices)
+	return report, nil
+}
+
+// GetMonthlyCostReport vergleicht die geplanten Kosten eines Monats mit dem Budget.
+// Berücksichtigt werden die Betriebstage aller vorhandenen Wochenpläne im Monat.
+func (a *App) GetMonthlyCostReport(year int, month int) (*MonthCostReport, error) {
+	if month < 1 || month > 12 {
+		return nil, fmt.Errorf("invalid month %d", month)
+	}
+	first := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+	last := first.AddDate(0, 1, -1)
+
+	plans, err := a.GetPlansInRange(isoweek.FormatDate(first), isoweek.FormatDate(last))
+	if err != nil {
+		return nil, err
+	}
+	budgets, err := loadDailyBudgets()
+	if err != nil {
+		return nil, err
+	}
+	prices, err := loadProductPrices()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &MonthCostReport{
+		Year:          year,
+		Month:         month,
+		Budgets:       budgetsInRange(budgets, isoweek.FormatDate(first), isoweek.FormatDate(last)),
+		Days:          []DayCost{},
+		MissingPrices: []string{},
+	}
+	for i := range plans {
+		headcounts, err := weekHeadcounts(&plans[i])
+		if err != nil {
+			return nil, err
+		}
+		days, _ := planCosts(&plans[i], headcounts, prices, budgets, &report.MissingPrices)
+		for _, d := range days {
+			if d.Date < isoweek.FormatDate(first) || d.Date > isoweek.FormatDate(last) {
+				continue
+			}
+			report.Days = append(report.Days, d)
+			report.Cost += d.Cost
+			report.Budget += d.Budget
+			report.ChildDays += d.Children
+		}
+	}
+	report.Difference = report.Budget - report.Cost
+	sort.Strings(report.MissingPrices)
+	return report, nil
+}
+
+// ExportMonthlyCostReportPDF exportiert den Kosten-Budget-Vergleich eines Monats (Hochformat A4)
+func (a *App) ExportMonthlyCostReportPDF(year int, month int, outputPath string) error {
+	report, err := a.GetMonthlyCostReport(year, month)
+	if err != nil {
+		return err
+	}
+
+	pdf := newReportPDF("P")
+	pdf.AddPage()
+
+	pageW, _ := pdf.GetPageSize()
+	marginX := 10.0
+	usableW := pageW - 2*marginX
+
+	pdf.SetFont("DejaVu", "B", 14)
+	pdf.CellFormat(usableW, 8, fmt.Sprintf("Verpflegungskosten %s %d", monthNames[month-1], year), "", 1, "L", false, 0, "")
+	pdf.SetFont("DejaVu", "", 9)
+	pdf.CellFormat(usableW, 5, "Budget je Kind und Tag: "+formatBudgets(report.Budgets), "", 1, "L", false, 0, "")
+	pdf.Ln(3)
+
+	widths := []float64{40, 25, 30, 30, 30, usableW - 155}
+	headers := []string{"Tag", "Kinder", "Kosten", "Budget", "Differenz", "je Kind"}
+	pdf.SetFont("DejaVu", "B", 8)
+	pdf.SetFillColor(220, 220, 220)
+	for i, h := range headers {
+		pdf.CellFormat(widths[i], 6, h, "1", 0, "L", true, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("DejaVu", "", 8)
+	for _, d := range report.Days {
+		date, _ := isoweek.ParseDate(d.Date)
+		perChild := "–"
+		if d.CostPerChild != nil {
+			perChild = formatEuro(*d.CostPerChild)
+		}
+		cells := []string{
+			fmt.Sprintf("%s, %s", weekdayName(d.Day)[:2], date.Format("02.01.2006")),
+			fmt.Sprint(d.Children),
+			formatEuro(d.Cost),
+			formatEuro(d.Budget),
+			formatEuro(d.Budget - d.Cost),
+			perChild,
+		}
+		if d.Cost > d.Budget {
+			pdf.SetTextColor(180, 0, 0)
+		}
+		for i, text := range cells {
+			pdf.CellFormat(widths[i], 5, text, "1", 0, "L", false, 0, "")
+		}
+		pdf.SetTextColor(0, 0, 0)
+		pdf.Ln(-1)
+	}
+	if len(report.Days) == 0 {
+		pdf.CellFormat(usableW, 6, "Keine Wochenpläne im gewählten Monat.", "", 1, "L", false, 0, "")
+	}
+
+	pdf.SetFont("DejaVu", "B", 8)
+	totals := []string{"Summe", fmt.Sprint(report.ChildDays), formatEuro(report.Cost), formatEuro(report.Budget), formatEuro(report.Difference), ""}
+	for i, text := range totals {
+		pdf.CellFormat(widths[i], 6, text, "1", 0, "L", true, 0, "")
+	}
+	pdf.Ln(-1)
+
+	if len(report.MissingPrices) > 0 {
+		pdf.Ln(3)
+		pdf.SetFont("DejaVu", "", 8)
+		pdf.MultiCell(usableW, 4, "Ohne Preis oder Portionsgröße (nicht in den Kosten enthalten): "+strings.Join(report.MissingPrices, ", "), "", "L", false)
+	}
+
+	return pdf.OutputFileAndClose(outputPath)
+}
+
+// HILFSFUNKTIONEN
+
+// loadDailyBudgets lädt alle Budgets, älteste zuerst, mit berechnetem Gültigkeitsende
+func loadDailyBudgets() ([]DailyBudget, error) {
+	budgets := []DailyBudget{}
+	if err := db.Select(&budgets, "SELECT id, amount, valid_from FROM daily_budgets ORDER BY valid_from"); err != nil {
+		return nil, fmt.Errorf("failed to load budgets: %w", err)
+	}
+	for i := 1; i < len(budgets); i++ {
+		from, _ := isoweek.ParseDate(budgets[i].ValidFrom)
+		to := isoweek.FormatDate(from.AddDate(0, 0, -1))
+		budgets[i-1].ValidTo = &to
+	}
+	return budgets, nil
+}
+
+// budgetAt gibt das an einem Datum (YYYY-MM-DD) gültige Budget je Kind zurück (0 = keins)
+func budgetAt(budgets []DailyBudget, date string) float64 {
+	amount := 0.0
+	for _, b := range budgets {
+		if b.ValidFrom <= date {
+			amount = b.Amount
+		}
+	}
+	return amount
+}
+
+// budgetsInRange gibt die Budgets zurück, die im Zeitraum from–to (YYYY-MM-DD) gelten
+func budgetsInRange(budgets []DailyBudget, from string, to string) []DailyBudget {
+	out := []DailyBudget{}
+	for _, b := range budgets {
+		if b.ValidFrom <= to && (b.ValidTo == nil || *b.ValidTo >= from) {
+			out = append(out, b)
+		}
+	}
+	return out
+}
+
+// migrateDailyBudget übernimmt das frühere einzelne Budget aus den Einstellungen als
+// Budget, das rückwirkend für alle bisherigen Pläne gilt
+func migrateDailyBudget() error {
+	var value string
+	err := db.Get(&value, "SELECT value FROM settings WHERE key = ?", settingDailyBudget)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load budget setting: %w", err)
+	}
+	amount, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return fmt.Errorf("invalid budget setting %q", value)
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("INSERT OR IGNORE INTO daily_budgets (amount, valid_from) VALUES (?, ?)", amount, "2000-01-01"); err != nil {
+		return fmt.Errorf("failed to migrate budget: %w", err)
+	}
+	if _, err := tx.Exec("DELETE FROM settings WHERE key = ?", settingDailyBudget); err != nil {
+		return fmt.Errorf("failed to remove budget setting: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// loadProductPrices lädt alle Preise je Produkt, älteste zuerst, mit berechnetem Gültigkeitsende
+func loadProductPrices() (map[int][]ProductPrice, error) {
+	var rows []ProductPrice
+	err := db.Select(&rows, "SELECT id, product_id, price_per_kg, valid_from FROM product_prices ORDER BY product_id, valid_from")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load product prices: %w", err)
+	}
+
+	prices := map[int][]ProductPrice{}
+	for _, p := range rows {
+		list := prices[p.ProductID]
+		if n := len(list); n > 0 {
+			from, _ := isoweek.ParseDate(p.ValidFrom)
+			to := isoweek.FormatDate(from.AddDate(0, 0, -1))
+			list[n-1].ValidTo = &to
+		}
+		prices[p.ProductID] = append(list, p)
+	}
+	return prices, nil
+}
+
+// priceAt gibt den an einem Datum (YYYY-MM-DD) gültigen Preis zurück (nil = keiner)
+func priceAt(prices []ProductPrice, date string) *ProductPrice {
+	var found *ProductPrice
+	for i := range prices {
+		if prices[i].ValidFrom <= date {
+			found = &prices[i]
+		}
+	}
+	return found
+}
+
+// planCosts berechnet Kosten je Betriebstag und je Planeintrag eines Wochenplans; das Budget
+// eines Tages richtet sich nach dem an diesem Tag gültigen Eintrag aus budgets.
+// Einträge ohne Preis oder Portionsgröße werden in missing vermerkt und nicht mitgerechnet.
+func planCosts(plan *WeekPlan, headcounts []WeekHeadcount, prices map[int][]ProductPrice, budgets []DailyBudget, missing *[]string) ([]DayCost, []CostLine) {
+	portions := buildPortionReport(plan, headcounts)
+
+	// Kinder je Tag: Gruppen, die an diesem Tag mindestens eine Mahlzeit erhalten
+	days := []DayCost{}
+	index := map[int]int{}
+	for _, h := range headcounts {
+		i, ok := index[h.Day]
+		if !ok {
+			i = len(days)
+			index[h.Day] = i
+			days = append(days, DayCost{Day: h.Day, Date: isoweek.FormatDate(isoweek.Day(plan.Year, plan.Week, h.Day))})
+		}
+		for _, served := range h.Meals {
+			if served > 0 {
+				days[i].Children += h.Count
+				break
+			}
+		}
+	}
+
+	lines := []CostLine{}
+	for _, l := range portions.Lines {
+		line := CostLine{Day: l.Day, Meal: l.Meal, ProductID: l.ProductID, Name: l.Name, Portions: l.Portions}
+		date := isoweek.FormatDate(isoweek.Day(plan.Year, plan.Week, l.Day))
+		if l.ProductID != nil && l.PortionSize != nil {
+			if price := priceAt(prices[*l.ProductID], date); price != nil {
+				perPortion := *l.PortionSize / 1000 * price.PricePerKg
+				cost := perPortion * float64(l.Portions)
+				line.CostPerPortion = &perPortion
+				line.Cost = &cost
+			}
+		}
+		if line.Cost == nil && l.Portions > 0 && !containsString(*missing, l.Name) {
+			*missing = append(*missing, l.Name)
+		}
+		if i, ok := index[l.Day]; ok && line.Cost != nil {
+			days[i].Cost += *line.Cost
+		}
+		lines = append(lines, line)
+	}
+
+	for i := range days {
+		days[i].BudgetPerChild = budgetAt(budgets, days[i].Date)
+		days[i].Budget = days[i].BudgetPerChild * float64(days[i].Children)
+		if days[i].Children > 0 {
+			perChild := days[i].Cost / float64(days[i].Children)
+			days[i].CostPerChild = &perChild
+		}
+	}
+	return days, lines
+}
+
+// formatBudgets beschreibt die Budgets eines Zeitraums, z.B. „4,00 € ab 01.03.2026“
+func formatBudgets(budgets []DailyBudget) string {
+	if len(budgets) == 0 {
+		return "nicht festgelegt"
+	}
+	parts := make([]string, 0, len(budgets))
+	for _, b := range budgets {
+		from, _ := isoweek.ParseDate(b.ValidFrom)
+		parts = append(parts, fmt.Sprintf("%s ab %s", formatEuro(b.Amount), from.Format("02.01.2006")))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatEuro formatiert einen Betrag im deutschen Format, z.B. „12,50 €“
+func formatEuro(v float64) string {
+	return strings.Replace(fmt.Sprintf("%.2f €", v), ".", ",", 1)
+}
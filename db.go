@@ -21,8 +21,9 @@ func InitDatabase() error {
 
 	dbPath := filepath.Join(homeDir, "speiseplan.db")
 	
-	// Verbindung zur Datenbank herstellen
-	database, err := sqlx.Open("sqlite", dbPath)
+	// Verbindung zur Datenbank herstellen; Fremdschlüssel werden über den DSN für jede
+	// Verbindung im Pool eingeschaltet, ein einzelnes PRAGMA gilt nur für eine davon
+	database, err := sqlx.Open("sqlite", "file:"+dbPath+"?_pragma=foreign_keys(1)")
 	if err != nil {
 		return fmt.Errorf("failed to open database: %w", err)
 	}
@@ -31,18 +32,27 @@ func InitDatabase() error {
 
 	// SQLite Pragmas setzen
 	db.MustExec("PRAGMA journal_mode=WAL")
-	db.MustExec("PRAGMA foreign_keys=ON")
 
 	// Schema erstellen
 	if err := createSchema(); err != nil {
 		return fmt.Errorf("failed to create schema: %w", err)
 	}
 
+	// Bestehende Datenbanken auf den aktuellen Stand bringen
+	if err := migrateSchema(); err != nil {
+		return fmt.Errorf("failed to migrate schema: %w", err)
+	}
+
 	// Seed-Daten einfügen
 	if err := SeedDatabase(); err != nil {
 		return fmt.Errorf("failed to seed database: %w", err)
 	}
 
+	// Produkte und Planeinträge ohne Revision (Altbestand, Seeds) nachtragen
+	if err := ensureProductRevisions(); err != nil {
+		return fmt.Errorf("failed to create product revisions: %w", err)
+	}
+
 	return nil
 }
 
@@ -66,9 +76,101 @@ func createSchema() error {
 	CREATE TABLE IF NOT EXISTS products (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		name TEXT NOT NULL UNIQUE,
-		multiline BOOLEAN DEFAULT FALSE
+		multiline BOOLEAN DEFAULT FALSE,
+		portion_size REAL,
+		vegetarian BOOLEAN NOT NULL DEFAULT FALSE,
+		vegan BOOLEAN NOT NULL DEFAULT FALSE,
+		pork_free BOOLEAN NOT NULL DEFAULT FALSE,
+		archived BOOLEAN NOT NULL DEFAULT FALSE
+	);
+
+	-- Nährwerte je 100 g (1:1, optional)
+	CREATE TABLE IF NOT EXISTS product_nutrition (
+		product_id INTEGER PRIMARY KEY REFERENCES products(id) ON DELETE CASCADE,
+		energy_kj REAL,
+		energy_kcal REAL,
+		protein REAL,
+		fat REAL,
+		saturated_fat REAL,
+		carbohydrates REAL,
+		sugar REAL,
+		fibre REAL,
+		salt REAL
+	);
+
+	-- Lebensmittelgruppen für die DGE-Prüfung
+	CREATE TABLE IF NOT EXISTS food_groups (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL
+	);
+
+	-- Produkt-Lebensmittelgruppen-Zuordnung (n:m)
+	CREATE TABLE IF NOT EXISTS product_food_groups (
+		product_id INTEGER REFERENCES products(id) ON DELETE CASCADE,
+		food_group_id TEXT REFERENCES food_groups(id) ON DELETE CASCADE,
+		PRIMARY KEY (product_id, food_group_id)
+	);
+
+	-- DGE-Regeln (Häufigkeiten bezogen auf 5 Verpflegungstage)
+	CREATE TABLE IF NOT EXISTS dge_rules (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		food_groups TEXT NOT NULL,
+		meal TEXT,
+		min_count INTEGER,
+		max_count INTEGER,
+		explanation TEXT NOT NULL DEFAULT '',
+		enabled BOOLEAN NOT NULL DEFAULT TRUE
+	);
+
+	-- Produktkategorien (hierarchisch)
+	CREATE TABLE IF NOT EXISTS categories (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL UNIQUE,
+		parent_id INTEGER REFERENCES categories(id) ON DELETE SET NULL,
+		color TEXT,
+		sort_order INTEGER NOT NULL DEFAULT 0
+	);
+
+	-- Produkt-Kategorie-Zuordnung (n:m)
+	CREATE TABLE IF NOT EXISTS product_categories (
+		product_id INTEGER REFERENCES products(id) ON DELETE CASCADE,
+		category_id INTEGER REFERENCES categories(id) ON DELETE CASCADE,
+		PRIMARY KEY (product_id, category_id)
+	);
+
+	-- Freie Schlagworte
+	CREATE TABLE IF NOT EXISTS tags (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL UNIQUE COLLATE NOCASE
+	);
+
+	-- Produkt-Schlagwort-Zuordnung (n:m)
+	CREATE TABLE IF NOT EXISTS product_tags (
+		product_id INTEGER REFERENCES products(id) ON DELETE CASCADE,
+		tag_id INTEGER REFERENCES tags(id) ON DELETE CASCADE,
+		PRIMARY KEY (product_id, tag_id)
+	);
+
+	-- Volltextindex über normalisierte Produktnamen (rowid = products.id)
+	CREATE VIRTUAL TABLE IF NOT EXISTS products_fts USING fts5(
+		normalized,
+		tokenize = 'unicode61 remove_diacritics 2'
 	);
 
+	CREATE TRIGGER IF NOT EXISTS products_fts_insert AFTER INSERT ON products BEGIN
+		INSERT INTO products_fts (rowid, normalized) VALUES (new.id, normalize_de(new.name));
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS products_fts_update AFTER UPDATE OF name ON products BEGIN
+		DELETE FROM products_fts WHERE rowid = old.id;
+		INSERT INTO products_fts (rowid, normalized) VALUES (new.id, normalize_de(new.name));
+	END;
+
+	CREATE TRIGGER IF NOT EXISTS products_fts_delete AFTER DELETE ON products BEGIN
+		DELETE FROM products_fts WHERE rowid = old.id;
+	END;
+
 	-- Produkt-Allergen-Zuordnung (n:m)
 	CREATE TABLE IF NOT EXISTS product_allergens (
 		product_id INTEGER REFERENCES products(id) ON DELETE CASCADE,
@@ -83,15 +185,45 @@ func createSchema() error {
 		PRIMARY KEY (product_id, additive_id)
 	);
 
+	-- Produktrevisionen (Stand von Name und Kennzeichnung je Änderung)
+	CREATE TABLE IF NOT EXISTS product_revisions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+		revision INTEGER NOT NULL,
+		name TEXT NOT NULL,
+		multiline BOOLEAN NOT NULL DEFAULT FALSE,
+		allergens TEXT NOT NULL DEFAULT '',
+		additives TEXT NOT NULL DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(product_id, revision)
+	);
+
 	-- Wochenpläne
 	CREATE TABLE IF NOT EXISTS week_plans (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		year INTEGER NOT NULL,
 		week INTEGER NOT NULL,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		rotation_id INTEGER REFERENCES rotations(id) ON DELETE SET NULL,
+		generated_hash TEXT,
+		status TEXT NOT NULL DEFAULT 'draft',
+		approved_by TEXT,
+		approved_at DATETIME,
+		archived BOOLEAN NOT NULL DEFAULT FALSE,
 		UNIQUE(year, week)
 	);
 
+	-- Sicherungen gelöschter Wochenpläne (vollständiger Plan als JSON)
+	CREATE TABLE IF NOT EXISTS week_plan_backups (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		week_plan_id INTEGER NOT NULL,
+		year INTEGER NOT NULL,
+		week INTEGER NOT NULL,
+		deleted_at DATETIME NOT NULL,
+		deleted_by TEXT NOT NULL,
+		data TEXT NOT NULL
+	);
+
 	-- Einträge im Wochenplan
 	CREATE TABLE IF NOT EXISTS plan_entries (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -100,23 +232,260 @@ func createSchema() error {
 		meal TEXT NOT NULL,
 		slot INTEGER NOT NULL DEFAULT 0,
 		product_id INTEGER REFERENCES products(id),
+		product_revision_id INTEGER REFERENCES product_revisions(id) ON DELETE SET NULL,
 		custom_text TEXT,
 		group_label TEXT
 	);
 
+	-- Rotationen (z.B. 4-Wochen-Zyklus), beginnend mit der Ankerwoche
+	CREATE TABLE IF NOT EXISTS rotations (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL UNIQUE COLLATE NOCASE,
+		anchor_year INTEGER NOT NULL,
+		anchor_week INTEGER NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	-- Vorlagenwochen (Rotationswochen und eigenständige Vorlagen)
+	CREATE TABLE IF NOT EXISTS template_weeks (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL DEFAULT '',
+		rotation_id INTEGER REFERENCES rotations(id) ON DELETE CASCADE,
+		position INTEGER NOT NULL DEFAULT 0,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	-- Einträge einer Vorlagenwoche
+	CREATE TABLE IF NOT EXISTS template_entries (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		template_week_id INTEGER NOT NULL REFERENCES template_weeks(id) ON DELETE CASCADE,
+		day INTEGER NOT NULL,
+		meal TEXT NOT NULL,
+		slot INTEGER NOT NULL DEFAULT 0,
+		product_id INTEGER REFERENCES products(id) ON DELETE CASCADE,
+		custom_text TEXT,
+		group_label TEXT
+	);
+
+	-- Änderungsprotokoll
+	CREATE TABLE IF NOT EXISTS audit_log (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		created_at DATETIME NOT NULL,
+		actor TEXT NOT NULL,
+		action TEXT NOT NULL,
+		entity TEXT NOT NULL,
+		entity_id INTEGER NOT NULL,
+		week_plan_id INTEGER,
+		before TEXT,
+		after TEXT,
+		diff TEXT NOT NULL DEFAULT '{}'
+	);
+	CREATE INDEX IF NOT EXISTS idx_audit_log_entity ON audit_log(entity, entity_id);
+	CREATE INDEX IF NOT EXISTS idx_audit_log_created ON audit_log(created_at);
+
+	-- Einrichtungseinstellungen (Schlüssel/Wert)
+	CREATE TABLE IF NOT EXISTS settings (
+		key TEXT PRIMARY KEY,
+		value TEXT NOT NULL
+	);
+
+	-- Verpflegungsbudget je Kind und Tag (Euro), gültig ab valid_from bis zum nächsten Budget
+	CREATE TABLE IF NOT EXISTS daily_budgets (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		amount REAL NOT NULL,
+		valid_from TEXT NOT NULL UNIQUE -- YYYY-MM-DD
+	);
+
+	-- Lieferanten (Liefertage kommagetrennt, 1=Mo … 7=So)
+	CREATE TABLE IF NOT EXISTS suppliers (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL UNIQUE COLLATE NOCASE,
+		contact TEXT NOT NULL DEFAULT '',
+		email TEXT NOT NULL DEFAULT '',
+		phone TEXT NOT NULL DEFAULT '',
+		customer_number TEXT NOT NULL DEFAULT '',
+		delivery_days TEXT NOT NULL DEFAULT '',
+		lead_time_days INTEGER NOT NULL DEFAULT 0
+	);
+
+	-- Lieferant je Produkt mit Artikelnummer und Gebindegröße (g)
+	CREATE TABLE IF NOT EXISTS product_suppliers (
+		product_id INTEGER PRIMARY KEY REFERENCES products(id) ON DELETE CASCADE,
+		supplier_id INTEGER NOT NULL REFERENCES suppliers(id) ON DELETE CASCADE,
+		article_number TEXT NOT NULL DEFAULT '',
+		pack_size REAL
+	);
+
+	-- Preisgeschichte je Produkt (Euro je kg), gültig ab valid_from bis zum nächsten Preis
+	CREATE TABLE IF NOT EXISTS product_prices (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+		price_per_kg REAL NOT NULL,
+		valid_from TEXT NOT NULL, -- YYYY-MM-DD
+		UNIQUE (product_id, valid_from)
+	);
+
+	-- Lagerartikel je Produkt mit Mindestbestand (g)
+	CREATE TABLE IF NOT EXISTS stock_items (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		product_id INTEGER NOT NULL UNIQUE REFERENCES products(id) ON DELETE CASCADE,
+		min_stock REAL NOT NULL DEFAULT 0,
+		location TEXT NOT NULL DEFAULT ''
+	);
+
+	-- Lagerbewegungen (Bestandsänderung in g); week_plan_id bei automatischem Verbrauch
+	CREATE TABLE IF NOT EXISTS stock_movements (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		stock_item_id INTEGER NOT NULL REFERENCES stock_items(id) ON DELETE CASCADE,
+		type TEXT NOT NULL,
+		quantity REAL NOT NULL,
+		created_at DATETIME NOT NULL,
+		actor TEXT NOT NULL,
+		note TEXT NOT NULL DEFAULT '',
+		week_plan_id INTEGER REFERENCES week_plans(id) ON DELETE SET NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_stock_movements_item ON stock_movements(stock_item_id);
+
+	-- Erwartete Kinderzahlen: Wochenmuster je Gruppe und Wochentag
+	CREATE TABLE IF NOT EXISTS headcount_defaults (
+		group_label TEXT NOT NULL,
+		day INTEGER NOT NULL,
+		count INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (group_label, day)
+	);
+
+	-- Abweichende Kinderzahlen einzelner Wochen
+	CREATE TABLE IF NOT EXISTS headcounts (
+		week_plan_id INTEGER NOT NULL REFERENCES week_plans(id) ON DELETE CASCADE,
+		group_label TEXT NOT NULL,
+		day INTEGER NOT NULL,
+		count INTEGER NOT NULL,
+		PRIMARY KEY (week_plan_id, group_label, day)
+	);
+
 	-- Sondertage
 	CREATE TABLE IF NOT EXISTS special_days (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
 		week_plan_id INTEGER REFERENCES week_plans(id) ON DELETE CASCADE,
 		day INTEGER NOT NULL,
 		type TEXT NOT NULL,
-		label TEXT
+		label TEXT,
+		meals TEXT NOT NULL DEFAULT '',
+		groups TEXT NOT NULL DEFAULT ''
 	);`
 
 	_, err := db.Exec(schema)
 	return err
 }
 
+// migrateSchema ergänzt Spalten, die in älteren Datenbanken noch fehlen
+func migrateSchema() error {
+	if err := addColumnIfMissing("products", "portion_size", "REAL"); err != nil {
+		return err
+	}
+	for _, column := range []string{"vegetarian", "vegan", "pork_free", "archived"} {
+		if err := addColumnIfMissing("products", column, "BOOLEAN NOT NULL DEFAULT FALSE"); err != nil {
+			return err
+		}
+	}
+
+	if err := addColumnIfMissing("plan_entries", "product_revision_id", "INTEGER REFERENCES product_revisions(id) ON DELETE SET NULL"); err != nil {
+		return err
+	}
+
+	// Herkunft generierter Wochenpläne (für „nur unberührte Wochen neu erzeugen“)
+	if err := addColumnIfMissing("week_plans", "rotation_id", "INTEGER REFERENCES rotations(id) ON DELETE SET NULL"); err != nil {
+		return err
+	}
+	if err := addColumnIfMissing("week_plans", "generated_hash", "TEXT"); err != nil {
+		return err
+	}
+
+	// Freigabe-Workflow
+	if err := addColumnIfMissing("week_plans", "status", "TEXT NOT NULL DEFAULT 'draft'"); err != nil {
+		return err
+	}
+	if err := addColumnIfMissing("week_plans", "approved_by", "TEXT"); err != nil {
+		return err
+	}
+	if err := addColumnIfMissing("week_plans", "approved_at", "DATETIME"); err != nil {
+		return err
+	}
+
+	// Sondertage nur für einzelne Mahlzeiten oder Gruppen
+	for _, column := range []string{"meals", "groups"} {
+		if err := addColumnIfMissing("special_days", column, "TEXT NOT NULL DEFAULT ''"); err != nil {
+			return err
+		}
+	}
+
+	// Archivierte (schreibgeschützte) Wochenpläne
+	if err := addColumnIfMissing("week_plans", "archived", "BOOLEAN NOT NULL DEFAULT FALSE"); err != nil {
+		return err
+	}
+
+	// Pro Tag und Bereich nur ein Sondertag; bei Dubletten gewinnt der zuletzt gesetzte
+	var hasSpecialIndex int
+	err := db.Get(&hasSpecialIndex, "SELECT COUNT(*) FROM sqlite_master WHERE type = 'index' AND name = 'idx_special_days_scope'")
+	if err != nil {
+		return fmt.Errorf("failed to inspect indexes: %w", err)
+	}
+	if hasSpecialIndex == 0 {
+		_, err := db.Exec(`
+			DELETE FROM special_days
+			WHERE id NOT IN (SELECT MAX(id) FROM special_days GROUP BY week_plan_id, day, meals, groups)
+		`)
+		if err != nil {
+			return fmt.Errorf("failed to remove duplicate special days: %w", err)
+		}
+		_, err = db.Exec("CREATE UNIQUE INDEX idx_special_days_scope ON special_days(week_plan_id, day, meals, groups)")
+		if err != nil {
+			return fmt.Errorf("failed to create special day index: %w", err)
+		}
+	}
+
+	// Slots lückenlos und eindeutig machen, bevor der Unique-Index greift
+	var hasSlotIndex int
+	err = db.Get(&hasSlotIndex, "SELECT COUNT(*) FROM sqlite_master WHERE type = 'index' AND name = 'idx_plan_entries_slot'")
+	if err != nil {
+		return fmt.Errorf("failed to inspect indexes: %w", err)
+	}
+	if hasSlotIndex == 0 {
+		if err := renumberSlots(db, "1 = 1"); err != nil {
+			return err
+		}
+		_, err := db.Exec("CREATE UNIQUE INDEX idx_plan_entries_slot ON plan_entries(week_plan_id, day, meal, slot)")
+		if err != nil {
+			return fmt.Errorf("failed to create slot index: %w", err)
+		}
+	}
+
+	if err := migrateDailyBudget(); err != nil {
+		return err
+	}
+
+	// Volltextindex nachziehen (z.B. nach Update aus einer Version ohne FTS)
+	return syncSearchIndex()
+}
+
+// addColumnIfMissing fügt eine Spalte hinzu, falls sie noch nicht existiert
+func addColumnIfMissing(table, column, definition string) error {
+	var count int
+	err := db.Get(&count, "SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?", table, column)
+	if err != nil {
+		return fmt.Errorf("failed to inspect table %s: %w", table, err)
+	}
+	if count > 0 {
+		return nil
+	}
+
+	_, err = db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	if err != nil {
+		return fmt.Errorf("failed to add column %s.%s: %w", table, column, err)
+	}
+	return nil
+}
+
 // GetDB gibt die Datenbankverbindung zurück
 func GetDB() *sqlx.DB {
 	return db
@@ -0,0 +1,335 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+
+	"speiseplan/isoweek"
+)
+
+// dgeRuleRow bildet eine Zeile aus dge_rules ab (Lebensmittelgruppen kommagetrennt)
+type dgeRuleRow struct {
+	ID          int     `db:"id"`
+	Name        string  `db:"name"`
+	FoodGroups  string  `db:"food_groups"`
+	Meal        *string `db:"meal"`
+	MinCount    *int    `db:"min_count"`
+	MaxCount    *int    `db:"max_count"`
+	Explanation string  `db:"explanation"`
+	Enabled     bool    `db:"enabled"`
+}
+
+func (r dgeRuleRow) toRule() DGERule {
+	return DGERule{
+		ID:          r.ID,
+		Name:        r.Name,
+		FoodGroups:  splitList(r.FoodGroups),
+		Meal:        r.Meal,
+		MinCount:    r.MinCount,
+		MaxCount:    r.MaxCount,
+		Explanation: r.Explanation,
+		Enabled:     r.Enabled,
+	}
+}
+
+// LEBENSMITTELGRUPPEN
+
+// GetFoodGroups gibt alle Lebensmittelgruppen zurück
+func (a *App) GetFoodGroups() ([]FoodGroup, error) {
+	var groups []FoodGroup
+	err := db.Select(&groups, "SELECT id, name FROM food_groups ORDER BY name")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get food groups: %w", err)
+	}
+	return groups, nil
+}
+
+// SetProductFoodGroups ordnet einem Produkt Lebensmittelgruppen zu
+func (a *App) SetProductFoodGroups(productID int, foodGroupIDs []string) (*Product, error) {
+	before, err := a.GetProduct(productID)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("DELETE FROM product_food_groups WHERE product_id = ?", productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete old food group links: %w", err)
+	}
+
+	for _, groupID := range foodGroupIDs {
+		_, err := tx.Exec("INSERT INTO product_food_groups (product_id, food_group_id) VALUES (?, ?)", productID, groupID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to link food group: %w", err)
+		}
+	}
+
+	after, err := a.auditProductUpdate(tx, before)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return after, nil
+}
+
+// DGE-REGELN
+
+// GetDGERules gibt alle DGE-Regeln zurück
+func (a *App) GetDGERules() ([]DGERule, error) {
+	var rows []dgeRuleRow
+	err := db.Select(&rows, `
+		SELECT id, name, food_groups, meal, min_count, max_count, explanation, enabled
+		FROM dge_rules
+		ORDER BY id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get DGE rules: %w", err)
+	}
+
+	rules := make([]DGERule, 0, len(rows))
+	for _, r := range rows {
+		rules = append(rules, r.toRule())
+	}
+	return rules, nil
+}
+
+// SaveDGERule legt eine Regel an (ID == 0) oder aktualisiert sie
+func (a *App) SaveDGERule(rule DGERule) (*DGERule, error) {
+	if strings.TrimSpace(rule.Name) == "" {
+		return nil, fmt.Errorf("rule name must not be empty")
+	}
+	if len(rule.FoodGroups) == 0 {
+		return nil, fmt.Errorf("rule needs at least one food group")
+	}
+	if rule.MinCount == nil && rule.MaxCount == nil {
+		return nil, fmt.Errorf("rule needs a minimum or maximum count")
+	}
+
+	groups := strings.Join(rule.FoodGroups, ",")
+	if rule.ID == 0 {
+		result, err := db.Exec(`
+			INSERT INTO dge_rules (name, food_groups, meal, min_count, max_count, explanation, enabled)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+		`, rule.Name, groups, rule.Meal, rule.MinCount, rule.MaxCount, rule.Explanation, rule.Enabled)
+		if err != nil {
+			return nil, fmt.Errorf("failed to insert DGE rule: %w", err)
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get rule ID: %w", err)
+		}
+		rule.ID = int(id)
+	} else {
+		_, err := db.Exec(`
+			UPDATE dge_rules
+			SET name = ?, food_groups = ?, meal = ?, min_count = ?, max_count = ?, explanation = ?, enabled = ?
+			WHERE id = ?
+		`, rule.Name, groups, rule.Meal, rule.MinCount, rule.MaxCount, rule.Explanation, rule.Enabled, rule.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update DGE rule: %w", err)
+		}
+	}
+
+	var row dgeRuleRow
+	err := db.Get(&row, `
+		SELECT id, name, food_groups, meal, min_count, max_count, explanation, enabled
+		FROM dge_rules WHERE id = ?
+	`, rule.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get DGE rule: %w", err)
+	}
+	saved := row.toRule()
+	return &saved, nil
+}
+
+// DeleteDGERule löscht eine DGE-Regel
+func (a *App) DeleteDGERule(id int) error {
+	_, err := db.Exec("DELETE FROM dge_rules WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete DGE rule: %w", err)
+	}
+	return nil
+}
+
+// DGE-PRÜFUNG
+
+// CheckDGEWeek prüft einen Wochenplan gegen die aktiven DGE-Regeln
+func (a *App) CheckDGEWeek(weekPlanID int) (*DGEReport, error) {
+	var plan WeekPlan
+	err := db.Get(&plan, "SELECT id, year, week, created_at FROM week_plans WHERE id = ?", weekPlanID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get week plan: %w", err)
+	}
+	return a.CheckDGERange(plan.Year, plan.Week, 1)
+}
+
+// CheckDGERange prüft weeks aufeinanderfolgende Wochen ab year/week (z.B. 4 Wochen = 20 Verpflegungstage)
+func (a *App) CheckDGERange(year int, week int, weeks int) (*DGEReport, error) {
+	if weeks < 1 {
+		return nil, fmt.Errorf("weeks must be at least 1")
+	}
+	if err := validateWeek(year, week); err != nil {
+		return nil, err
+	}
+
+	rules, err := a.GetDGERules()
+	if err != nil {
+		return nil, err
+	}
+
+	var plans []WeekPlan
+	var missing []string
+	for i := 0; i < weeks; i++ {
+		y, w := isoweek.AddWeeks(year, week, i)
+		plan, err := a.GetWeekPlan(y, w)
+		if err != nil {
+			if isNotFound(err) {
+				missing = append(missing, fmt.Sprintf("KW %d/%d", w, y))
+				continue
+			}
+			return nil, err
+		}
+		plans = append(plans, *plan)
+	}
+
+	days, err := operatingDays()
+	if err != nil {
+		return nil, err
+	}
+
+	report := evaluateDGE(plans, rules, days)
+	report.Year = year
+	report.Week = week
+	report.Weeks = weeks
+	report.MissingWeeks = missing
+	return report, nil
+}
+
+// evaluateDGE wertet die Regeln über alle Verpflegungstage der übergebenen Pläne aus
+func evaluateDGE(plans []WeekPlan, rules []DGERule, days []int) *DGEReport {
+	report := &DGEReport{Passed: true}
+
+	// Verpflegungstage: Betriebstage ohne ganztägige Sondertage
+	type dayKey struct{ plan, day int }
+	operating := map[dayKey]bool{}
+	for _, plan := range plans {
+		for _, day := range days {
+			if closingSpecial(plan.SpecialDays, day) == nil {
+				operating[dayKey{plan.ID, day}] = true
+			}
+		}
+	}
+	report.OperatingDays = len(operating)
+
+	for _, rule := range rules {
+		if !rule.Enabled {
+			continue
+		}
+
+		wanted := map[string]bool{}
+		for _, g := range rule.FoodGroups {
+			wanted[g] = true
+		}
+
+		// Tage zählen, an denen mindestens ein passendes Produkt angeboten wurde
+		hits := map[dayKey]bool{}
+		for _, plan := range plans {
+			for _, e := range plan.Entries {
+				key := dayKey{plan.ID, e.Day}
+				if !operating[key] || e.Product == nil || entryBlocked(plan.SpecialDays, e.Day, e.Meal, e.GroupLabel) {
+					continue
+				}
+				if rule.Meal != nil && *rule.Meal != e.Meal {
+					continue
+				}
+				for _, g := range e.Product.FoodGroups {
+					if wanted[g.ID] {
+						hits[key] = true
+						break
+					}
+				}
+			}
+		}
+
+		finding := DGEFinding{
+			RuleID:      rule.ID,
+			RuleName:    rule.Name,
+			Passed:      true,
+			Count:       len(hits),
+			Explanation: rule.Explanation,
+		}
+
+		// Häufigkeiten von 5 Verpflegungstagen auf den Zeitraum hochrechnen
+		factor := float64(report.OperatingDays) / 5.0
+		if rule.MinCount != nil {
+			minDays := int(math.Ceil(float64(*rule.MinCount) * factor))
+			finding.Min = &minDays
+			if finding.Count < minDays {
+				finding.Passed = false
+				finding.Message = fmt.Sprintf("%d von mindestens %d Tagen", finding.Count, minDays)
+			}
+		}
+		if rule.MaxCount != nil {
+			maxDays := int(math.Floor(float64(*rule.MaxCount) * factor))
+			finding.Max = &maxDays
+			if finding.Count > maxDays {
+				finding.Passed = false
+				finding.Message = fmt.Sprintf("%d Tage, höchstens %d erlaubt", finding.Count, maxDays)
+			}
+		}
+		if finding.Passed {
+			finding.Message = fmt.Sprintf("an %d von %d Tagen erfüllt", finding.Count, report.OperatingDays)
+		} else {
+			report.Passed = false
+		}
+
+		report.Findings = append(report.Findings, finding)
+	}
+
+	return report
+}
+
+// loadProductFoodGroups lädt die Lebensmittelgruppen eines Produkts
+func loadProductFoodGroups(q sqlx.Queryer, product *Product) error {
+	query := `
+		SELECT fg.id, fg.name
+		FROM food_groups fg
+		JOIN product_food_groups pfg ON fg.id = pfg.food_group_id
+		WHERE pfg.product_id = ?
+		ORDER BY fg.id
+	`
+	err := sqlx.Select(q, &product.FoodGroups, query, product.ID)
+	if err != nil {
+		return fmt.Errorf("failed to load food groups for product %d: %w", product.ID, err)
+	}
+	return nil
+}
+
+// isNotFound prüft, ob ein (gewrappter) Fehler auf eine fehlende Zeile zurückgeht
+func isNotFound(err error) bool {
+	return errors.Is(err, sql.ErrNoRows)
+}
+
+// splitList zerlegt eine kommagetrennte Liste und entfernt Leereinträge
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
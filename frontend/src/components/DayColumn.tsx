@@ -1,4 +1,4 @@
-import { WeekDay, PlanEntry, SpecialDay, DAY_NAMES, GroupLabel } from '../types';
+import { WeekDay, PlanEntry, SpecialDay, SpecialDayType, DAY_NAMES, SPECIAL_DAY_NAMES, GroupLabel } from '../types';
 import { MealSlot } from './MealSlot';
 import { SpecialDayDisplay } from './SpecialDayDialog';
 
@@ -27,6 +27,8 @@ export function DayColumn({
 }: DayColumnProps) {
   const dayName = DAY_NAMES[day];
   const isSpecialDay = !!specialDay;
+  // Nur Sondertage ohne Mahlzeiten- und Gruppenauswahl belegen den ganzen Tag
+  const closesDay = isSpecialDay && !specialDay!.meals?.length && !specialDay!.groups?.length;
 
   // Filter entries by meal
   const breakfastEntries = entries.filter(e => e.meal === 'fruehstueck');
@@ -82,7 +84,7 @@ export function DayColumn({
 
       {/* Content */}
       <div className="p-4">
-        {isSpecialDay ? (
+        {closesDay ? (
           /* Special Day Display */
           <SpecialDayDisplay
             specialDay={specialDay!}
@@ -92,6 +94,15 @@ export function DayColumn({
         ) : (
           /* Regular Day Content */
           <div className="space-y-6">
+            {/* Partial Special Day */}
+            {isSpecialDay && (
+              <SpecialDayDisplay
+                specialDay={specialDay!}
+                day={day}
+                onClick={() => onSetSpecialDay?.(day)}
+              />
+            )}
+
             {/* Breakfast */}
             <MealSlot
               day={day}
@@ -123,7 +134,7 @@ export function DayColumn({
       </div>
 
       {/* Footer with stats */}
-      {!isSpecialDay && (
+      {!closesDay && (
         <div className="px-4 py-2 bg-gray-50 border-t border-gray-200">
           <div className="flex items-center justify-between text-xs text-gray-500">
             <span>
@@ -185,7 +196,7 @@ export function DayHeader({
       </div>
       {isSpecialDay && (
         <div className="text-xs mt-1 font-medium">
-          {specialDay!.type === 'feiertag' ? 'Feiertag' : 'Schließtag'}
+          {SPECIAL_DAY_NAMES[specialDay!.type as SpecialDayType] ?? specialDay!.type}
         </div>
       )}
     </div>
@@ -1,10 +1,10 @@
 import { useState } from 'react';
-import { SpecialDay, SpecialDayType, WeekDay, DAY_NAMES, SPECIAL_DAY_NAMES } from '../types';
+import { SpecialDay, SpecialDayType, WeekDay, MealType, DAY_NAMES, MEAL_NAMES, GROUP_LABELS, SPECIAL_DAY_NAMES, SPECIAL_DAY_DESCRIPTIONS, SPECIAL_DAY_TYPES } from '../types';
 
 interface SpecialDayDialogProps {
   day: WeekDay;
   existingSpecialDay?: SpecialDay;
-  onSave: (day: WeekDay, type: SpecialDayType, label?: string) => void;
+  onSave: (day: WeekDay, type: SpecialDayType, label: string | undefined, meals: MealType[], groups: string[]) => void;
   onRemove?: (day: WeekDay) => void;
   onCancel: () => void;
 }
@@ -20,12 +20,18 @@ export function SpecialDayDialog({
     (existingSpecialDay?.type as SpecialDayType) || 'feiertag'
   );
   const [label, setLabel] = useState(existingSpecialDay?.label || '');
+  const [meals, setMeals] = useState<MealType[]>(existingSpecialDay?.meals ?? []);
+  const [groups, setGroups] = useState<string[]>(existingSpecialDay?.groups ?? []);
 
   const handleSubmit = (e: React.FormEvent) => {
     e.preventDefault();
-    onSave(day, type, label.trim() || undefined);
+    onSave(day, type, label.trim() || undefined, meals, groups);
   };
 
+  // Leere Auswahl bedeutet „alle“
+  const toggle = <T,>(list: T[], value: T): T[] =>
+    list.includes(value) ? list.filter(v => v !== value) : [...list, value];
+
   const handleRemove = () => {
     if (onRemove) {
       onRemove(day);
@@ -70,43 +76,70 @@ export function SpecialDayDialog({
                   Art des Sondertags
                 </legend>
                 <div className="space-y-2">
-                  <label className="flex items-center space-x-3 cursor-pointer min-h-[44px]">
-                    <input
-                      type="radio"
-                      value="feiertag"
-                      checked={type === 'feiertag'}
-                      onChange={(e) => setType(e.target.value as SpecialDayType)}
-                      className="w-4 h-4 text-primary bg-gray-100 border-gray-300 focus:ring-primary focus:ring-2"
-                    />
-                    <div className="flex-1">
-                      <div className="text-sm font-medium text-gray-900">
-                        {SPECIAL_DAY_NAMES.feiertag}
-                      </div>
-                      <div className="text-sm text-gray-500">
-                        Gesetzlicher oder betrieblicher Feiertag
-                      </div>
-                    </div>
-                  </label>
-
-                  <label className="flex items-center space-x-3 cursor-pointer min-h-[44px]">
-                    <input
-                      type="radio"
-                      value="schliesstag"
-                      checked={type === 'schliesstag'}
-                      onChange={(e) => setType(e.target.value as SpecialDayType)}
-                      className="w-4 h-4 text-primary bg-gray-100 border-gray-300 focus:ring-primary focus:ring-2"
-                    />
-                    <div className="flex-1">
-                      <div className="text-sm font-medium text-gray-900">
-                        {SPECIAL_DAY_NAMES.schliesstag}
+                  {SPECIAL_DAY_TYPES.map(t => (
+                    <label key={t} className="flex items-center space-x-3 cursor-pointer min-h-[44px]">
+                      <input
+                        type="radio"
+                        value={t}
+                        checked={type === t}
+                        onChange={(e) => setType(e.target.value as SpecialDayType)}
+                        className="w-4 h-4 text-primary bg-gray-100 border-gray-300 focus:ring-primary focus:ring-2"
+                      />
+                      <div className="flex-1">
+                        <div className="text-sm font-medium text-gray-900">
+                          {SPECIAL_DAY_NAMES[t]}
+                        </div>
+                        <div className="text-sm text-gray-500">
+                          {SPECIAL_DAY_DESCRIPTIONS[t]}
+                        </div>
                       </div>
-                      <div className="text-sm text-gray-500">
-                        Kita ist geschlossen (z.B. Betriebsurlaub)
-                      </div>
-                    </div>
-                  </label>
+                    </label>
+                  ))}
+                </div>
+              </fieldset>
+            </div>
+
+            {/* Scope Selection */}
+            <div>
+              <fieldset>
+                <legend className="text-sm font-medium text-gray-700 mb-2">
+                  Betroffene Mahlzeiten
+                </legend>
+                <div className="flex flex-wrap gap-4">
+                  {(Object.keys(MEAL_NAMES) as MealType[]).map(m => (
+                    <label key={m} className="flex items-center space-x-2 cursor-pointer">
+                      <input
+                        type="checkbox"
+                        checked={meals.includes(m)}
+                        onChange={() => setMeals(toggle(meals, m))}
+                        className="w-4 h-4 text-primary border-gray-300 rounded focus:ring-primary"
+                      />
+                      <span className="text-sm text-gray-900">{MEAL_NAMES[m]}</span>
+                    </label>
+                  ))}
+                </div>
+              </fieldset>
+              <fieldset className="mt-3">
+                <legend className="text-sm font-medium text-gray-700 mb-2">
+                  Betroffene Gruppen
+                </legend>
+                <div className="flex flex-wrap gap-4">
+                  {GROUP_LABELS.map(g => (
+                    <label key={g} className="flex items-center space-x-2 cursor-pointer">
+                      <input
+                        type="checkbox"
+                        checked={groups.includes(g)}
+                        onChange={() => setGroups(toggle(groups, g))}
+                        className="w-4 h-4 text-primary border-gray-300 rounded focus:ring-primary"
+                      />
+                      <span className="text-sm text-gray-900">{g}</span>
+                    </label>
+                  ))}
                 </div>
               </fieldset>
+              <p className="mt-1 text-sm text-gray-500">
+                Ohne Auswahl gilt der Sondertag für den ganzen Tag bzw. alle Gruppen
+              </p>
             </div>
 
             {/* Label Input */}
@@ -151,7 +184,7 @@ export function SpecialDayDialog({
                   </div>
                 )}
                 <div className="text-xs mt-1 opacity-75">
-                  {DAY_NAMES[day]}
+                  {DAY_NAMES[day]}{scopeText(meals, groups) && ` · ${scopeText(meals, groups)}`}
                 </div>
               </div>
             </div>
@@ -236,9 +269,28 @@ export function SpecialDayDisplay({
           {specialDay.label}
         </div>
       )}
+      {scopeText(specialDay.meals ?? [], specialDay.groups ?? []) && (
+        <div className="text-xs mt-1">
+          {scopeText(specialDay.meals ?? [], specialDay.groups ?? [])}
+        </div>
+      )}
       <div className="text-xs mt-1 opacity-75">
         Klicken zum Bearbeiten
       </div>
     </div>
   );
-}
\ No newline at end of file
+}
+
+// Schlüssel für Tag-Bereich-Vergleiche wie im Backend; alle Mahlzeiten = ganzer Tag
+export function specialDayScopeKey(meals: MealType[], groups: string[]): string {
+  const allMeals = meals.length === Object.keys(MEAL_NAMES).length;
+  return `${allMeals ? '' : [...meals].sort().join(',')}|${[...groups].sort().join(',')}`;
+}
+
+// Beschreibt den Geltungsbereich eines Sondertags, leer = ganzer Tag für alle Gruppen
+export function scopeText(meals: MealType[], groups: string[]): string {
+  const parts: string[] = [];
+  if (meals.length) parts.push(`nur ${meals.map(m => MEAL_NAMES[m]).join(', ')}`);
+  if (groups.length) parts.push(groups.join(', '));
+  return parts.join(' · ');
+}
@@ -1,13 +1,24 @@
-import { useState } from 'react';
-import { WeekPlan, WeekDay, GroupLabel, SpecialDayType } from '../types';
+import { useState, useEffect } from 'react';
+import { WeekPlan, WeekDay, GroupLabel, MealType, SpecialDayType, DAY_NAMES, DEFAULT_OPERATING_DAYS } from '../types';
 import { DayColumn } from './DayColumn';
-import { SpecialDayDialog } from './SpecialDayDialog';
+import { SpecialDayDialog, specialDayScopeKey } from './SpecialDayDialog';
 import { getWeekDays } from '../lib/weekHelper';
 import { useWeekPlan } from '../hooks/useWeekPlan';
 
 // Import der Wails-Funktionen (werden zur Laufzeit verfügbar sein)
 // @ts-ignore - Wails-Bindings werden zur Laufzeit generiert
-import { ExportPDF } from '../../wailsjs/go/main/App';
+import { ExportPDF, GetOperatingDays } from '../../wailsjs/go/main/App';
+
+// Spaltenklassen je Anzahl Betriebstage (ausgeschrieben, damit Tailwind sie findet)
+const GRID_COLUMNS: Record<number, string> = {
+  1: 'lg:grid-cols-1',
+  2: 'lg:grid-cols-2',
+  3: 'lg:grid-cols-3',
+  4: 'lg:grid-cols-4',
+  5: 'lg:grid-cols-5',
+  6: 'lg:grid-cols-6',
+  7: 'lg:grid-cols-7'
+};
 
 interface WeekPlannerProps {
   year: number;
@@ -20,6 +31,14 @@ export function WeekPlanner({ year, week, className = '' }: WeekPlannerProps) {
   const [pdfExporting, setPdfExporting] = useState(false);
   const [pdfSuccess, setPdfSuccess] = useState<string | null>(null);
   const [pdfError, setPdfError] = useState<string | null>(null);
+  const [operatingDays, setOperatingDays] = useState<WeekDay[]>(DEFAULT_OPERATING_DAYS);
+
+  // Betriebstage der Einrichtung laden
+  useEffect(() => {
+    GetOperatingDays()
+      .then((days: WeekDay[]) => setOperatingDays(days))
+      .catch(() => setOperatingDays(DEFAULT_OPERATING_DAYS));
+  }, []);
   
   const {
     weekPlan,
@@ -36,7 +55,7 @@ export function WeekPlanner({ year, week, className = '' }: WeekPlannerProps) {
   } = useWeekPlan(year, week);
 
   // Get week days
-  const weekDays = getWeekDays(year, week);
+  const weekDays = getWeekDays(year, week, operatingDays);
 
   // Handle adding entry
   const handleAddEntry = async (
@@ -60,8 +79,13 @@ export function WeekPlanner({ year, week, className = '' }: WeekPlannerProps) {
   };
 
   // Handle saving special day
-  const handleSaveSpecialDay = async (day: WeekDay, type: SpecialDayType, label?: string) => {
-    await setSpecialDay(day, type, label);
+  const handleSaveSpecialDay = async (day: WeekDay, type: SpecialDayType, label?: string, meals: MealType[] = [], groups: string[] = []) => {
+    // Geänderter Bereich: bisherigen Sondertag ersetzen statt einen weiteren anzulegen
+    const existing = getSpecialDay(day);
+    if (existing && specialDayScopeKey(existing.meals ?? [], existing.groups ?? []) !== specialDayScopeKey(meals, groups)) {
+      await removeSpecialDay(day, existing);
+    }
+    await setSpecialDay(day, type, label, meals, groups);
     setSpecialDayDialog(null);
   };
 
@@ -139,7 +163,7 @@ export function WeekPlanner({ year, week, className = '' }: WeekPlannerProps) {
           }
           .week-grid { 
             display: grid; 
-            grid-template-columns: repeat(5, 1fr); 
+            grid-template-columns: repeat(${weekDays.length}, 1fr); 
             gap: 15px; 
             margin-bottom: 30px;
           }
@@ -198,8 +222,8 @@ export function WeekPlanner({ year, week, className = '' }: WeekPlannerProps) {
         
         <div class="week-grid">
           ${weekDays.map((date, index) => {
-            const dayNumber = (index + 1) as WeekDay;
-            const dayName = ['Montag', 'Dienstag', 'Mittwoch', 'Donnerstag', 'Freitag'][index];
+            const dayNumber = operatingDays[index];
+            const dayName = DAY_NAMES[dayNumber];
             const dateStr = date.toLocaleDateString('de-DE', { day: '2-digit', month: '2-digit' });
             
             const specialDay = getSpecialDay(dayNumber);
@@ -463,9 +487,9 @@ export function WeekPlanner({ year, week, className = '' }: WeekPlannerProps) {
       )}
 
       {/* Week Grid */}
-      <div className="grid grid-cols-1 md:grid-cols-2 lg:grid-cols-5 gap-4 lg:gap-6">
+      <div className={`grid grid-cols-1 md:grid-cols-2 ${GRID_COLUMNS[operatingDays.length]} gap-4 lg:gap-6`}>
         {weekDays.map((date, index) => {
-          const dayNumber = (index + 1) as WeekDay;
+          const dayNumber = operatingDays[index];
           const dayEntries = getEntriesForDay(dayNumber, 'fruehstueck').concat(
             getEntriesForDay(dayNumber, 'vesper')
           );
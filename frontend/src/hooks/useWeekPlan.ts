@@ -3,7 +3,8 @@ import { WeekPlan, PlanEntry, SpecialDay, MealType, WeekDay, GroupLabel } from '
 
 // Import der Wails-Funktionen (werden zur Laufzeit verfügbar sein)
 // @ts-ignore - Wails-Bindings werden zur Laufzeit generiert
-import { GetWeekPlan, CreateWeekPlan, CopyWeekPlan, AddPlanEntry, RemovePlanEntry, UpdatePlanEntry, SetSpecialDay, RemoveSpecialDay } from '../../wailsjs/go/main/App';
+import { GetWeekPlan, CreateWeekPlan, CopyWeekPlan, AddPlanEntry, RemovePlanEntry, UpdatePlanEntry, SetSpecialDayWithScope, RemoveSpecialDay, RemoveSpecialDayWithScope } from '../../wailsjs/go/main/App';
+import { specialDayScopeKey } from '../components/SpecialDayDialog';
 
 export function useWeekPlan(year: number, week: number) {
   const [weekPlan, setWeekPlan] = useState<WeekPlan | null>(null);
@@ -130,11 +131,17 @@ export function useWeekPlan(year: number, week: number) {
   };
 
   // Sondertag setzen
-  const setSpecialDay = async (day: WeekDay, type: string, label?: string): Promise<boolean> => {
+  const setSpecialDay = async (
+    day: WeekDay,
+    type: string,
+    label?: string,
+    meals: MealType[] = [],
+    groups: string[] = []
+  ): Promise<boolean> => {
     if (!weekPlan) return false;
     
     try {
-      await SetSpecialDay(weekPlan.id, day, type, label ?? '');
+      await SetSpecialDayWithScope(weekPlan.id, day, type, label ?? '', meals, groups);
       
       // State aktualisieren
       const newSpecialDay: SpecialDay = {
@@ -142,12 +149,20 @@ export function useWeekPlan(year: number, week: number) {
         week_plan_id: weekPlan.id,
         day,
         type,
-        label
+        label,
+        meals,
+        groups
       };
       
       setWeekPlan(prev => prev ? {
         ...prev,
-        special_days: [...prev.special_days.filter(s => s.day !== day), newSpecialDay]
+        // Pro Tag und Bereich gibt es nur einen Sondertag
+        special_days: [
+          ...prev.special_days.filter(s =>
+            s.day !== day || specialDayScopeKey(s.meals ?? [], s.groups ?? []) !== specialDayScopeKey(meals, groups)
+          ),
+          newSpecialDay
+        ]
       } : null);
       
       return true;
@@ -157,17 +172,21 @@ export function useWeekPlan(year: number, week: number) {
     }
   };
 
-  // Sondertag entfernen
-  const removeSpecialDay = async (day: WeekDay): Promise<boolean> => {
+  // Sondertag entfernen (ohne only: alle Sondertage des Tages)
+  const removeSpecialDay = async (day: WeekDay, only?: SpecialDay): Promise<boolean> => {
     if (!weekPlan) return false;
     
     try {
-      await RemoveSpecialDay(weekPlan.id, day);
+      if (only) {
+        await RemoveSpecialDayWithScope(weekPlan.id, day, only.meals ?? [], only.groups ?? []);
+      } else {
+        await RemoveSpecialDay(weekPlan.id, day);
+      }
       
       // State aktualisieren
       setWeekPlan(prev => prev ? {
         ...prev,
-        special_days: prev.special_days.filter(s => s.day !== day)
+        special_days: prev.special_days.filter(s => s.day !== day || (only !== undefined && s.id !== only.id))
       } : null);
       
       return true;
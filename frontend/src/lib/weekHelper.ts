@@ -52,15 +52,15 @@ export function getDateFromWeek(year: number, week: number): Date {
 }
 
 /**
- * Berechnet alle Tage einer Kalenderwoche (Mo-Fr)
+ * Berechnet die Daten der übergebenen Wochentage einer Kalenderwoche (1=Mo … 7=So, Standard Mo-Fr)
  */
-export function getWeekDays(year: number, week: number): Date[] {
+export function getWeekDays(year: number, week: number, weekDays: number[] = [1, 2, 3, 4, 5]): Date[] {
   const monday = getDateFromWeek(year, week);
   const days: Date[] = [];
   
-  for (let i = 0; i < 5; i++) { // Nur Mo-Fr
+  for (const weekDay of weekDays) {
     const day = new Date(monday);
-    day.setDate(monday.getDate() + i);
+    day.setDate(monday.getDate() + weekDay - 1);
     days.push(day);
   }
   
@@ -15,27 +15,277 @@ export interface Product {
   id: number;
   name: string;
   multiline: boolean;
+  portion_size?: number; // g
+  vegetarian: boolean;
+  vegan: boolean;
+  pork_free: boolean;
+  archived: boolean;
+  revision: number;
   allergens: Allergen[];
   additives: Additive[];
+  food_groups: FoodGroup[];
+  categories: Category[];
+  tags: Tag[];
+  nutrition?: Nutrition; // je 100 g
+  nutrition_per_portion?: Nutrition;
 }
 
+export interface Category {
+  id: number;
+  name: string;
+  parent_id?: number;
+  color?: string; // '#RRGGBB'
+  sort_order: number;
+  children?: Category[];
+}
+
+export interface Tag {
+  id: number;
+  name: string;
+}
+
+export interface ProductFilter {
+  category_id?: number; // inkl. Unterkategorien
+  tag_ids?: number[];
+  exclude_allergens?: string[];
+  vegetarian?: boolean;
+  vegan?: boolean;
+  pork_free?: boolean;
+  include_archived?: boolean;
+}
+
+export interface ProductUsage {
+  week_plan_id: number;
+  year: number;
+  week: number;
+  entries: number;
+}
+
+export interface TemplateUsage {
+  template_week_id: number;
+  name: string;
+  rotation_name?: string; // leer = eigenständige Vorlage
+  entries: number;
+}
+
+export interface ProductUsageReport {
+  plans: ProductUsage[];
+  templates: TemplateUsage[];
+}
+
+export interface MergeResult {
+  target: Product;
+  entries_moved: number;
+  duplicates_removed: number;
+}
+
+export interface FoodGroup {
+  id: string;
+  name: string;
+}
+
+export interface Nutrition {
+  energy_kj?: number;
+  energy_kcal?: number;
+  protein?: number;
+  fat?: number;
+  saturated_fat?: number;
+  carbohydrates?: number;
+  sugar?: number;
+  fibre?: number;
+  salt?: number;
+}
+
+export interface DayNutrition {
+  day: number;
+  totals: Nutrition;
+  counted: number;
+  missing: number;
+}
+
+export interface NutritionSummary {
+  week_plan_id: number;
+  year: number;
+  week: number;
+  days: DayNutrition[];
+  totals: Nutrition;
+  missing: number;
+}
+
+export interface ProductRevision {
+  id: number;
+  product_id: number;
+  revision: number;
+  name: string;
+  multiline: boolean;
+  allergen_ids: string[];
+  additive_ids: string[];
+  created_at: string;
+}
+
+export interface PlanSelection {
+  year: number;
+  week: number;
+  day: number;   // 0 = ganze Woche
+  days?: number; // Anzahl Tage ab day
+  meal?: MealType | '';
+}
+
+export type CopyMode = 'append' | 'replace' | 'skip';
+
+export interface CopyOptions {
+  mode: CopyMode;
+  include_special_days: boolean;
+}
+
+export type CopyConflictMode = 'fail' | 'overwrite' | 'merge';
+
+export interface CopyResult {
+  plan: WeekPlan;
+  created: boolean;
+  copied: PlanEntry[];
+  blocked: PlanEntry[];
+  skipped: PlanEntry[];
+  removed: number;
+  special_days_copied: number;
+  special_days_skipped: number;
+}
+
+export interface Rotation {
+  id: number;
+  name: string;
+  anchor_year: number;
+  anchor_week: number;
+  created_at: string;
+  weeks: TemplateWeek[];
+}
+
+export interface TemplateWeek {
+  id: number;
+  name: string;
+  rotation_id?: number;
+  position: number;
+  created_at: string;
+  entries: TemplateEntry[];
+}
+
+export interface TemplateEntry {
+  id: number;
+  template_week_id: number;
+  day: number;
+  meal: MealType;
+  slot: number;
+  product_id?: number;
+  product?: Product;
+  custom_text?: string;
+  group_label?: GroupLabel;
+}
+
+export interface WeekRef {
+  year: number;
+  week: number;
+}
+
+export interface RotationResult {
+  generated: WeekRef[];
+  skipped: WeekRef[];
+}
+
+export interface GeneratorOptions {
+  seed: number;
+  meals?: MealType[];
+  entries_per_meal?: number;
+  no_repeat_days?: number;
+  history_weeks?: number;
+  filter?: ProductFilter;
+  profiles?: GeneratorProfile[];
+}
+
+export interface GeneratorProfile {
+  group_label: string;
+  filter: ProductFilter;
+}
+
+export interface AuditEntry {
+  id: number;
+  created_at: string;
+  actor: string;
+  action: string; // 'create' | 'update' | 'delete' | 'copy'
+  entity: 'product' | 'week_plan' | 'plan_entry' | 'special_day';
+  entity_id: number;
+  week_plan_id?: number;
+  before?: string; // JSON
+  after?: string;  // JSON
+  diff: string;    // JSON: Feld → AuditChange
+}
+
+export interface AuditChange {
+  before: unknown;
+  after: unknown;
+}
+
+export interface AuditFilter {
+  entity?: string;
+  entity_id?: number;
+  week_plan_id?: number;
+  actor?: string;
+  from?: string; // YYYY-MM-DD
+  to?: string;   // YYYY-MM-DD
+  limit?: number;
+}
+
+export type PlanStatus = 'draft' | 'review' | 'approved' | 'published' | 'served';
+
 export interface WeekPlan {
   id: number;
   year: number;
   week: number;
   created_at: string;
+  status: PlanStatus;
+  approved_by?: string;
+  approved_at?: string;
+  archived: boolean; // schreibgeschützt
   entries: PlanEntry[];
   special_days: SpecialDay[];
+  days: PlanDay[]; // Kalenderdaten Mo-So
+}
+
+export interface WeekPlanDeletion {
+  week_plan_id: number;
+  year: number;
+  week: number;
+  entries: number;
+  special_days: number;
+  token: string; // an DeleteWeekPlan übergeben
+}
+
+export interface WeekPlanBackup {
+  id: number;
+  week_plan_id: number;
+  year: number;
+  week: number;
+  deleted_at: string;
+  deleted_by: string;
+}
+
+export interface PlanDay {
+  day: number;  // 1=Montag … 7=Sonntag
+  date: string; // YYYY-MM-DD
+  name: string;
+  operating: boolean;
 }
 
 export interface PlanEntry {
   id: number;
   week_plan_id: number;
-  day: number; // 1=Montag, 2=Dienstag, ..., 5=Freitag
+  day: number; // 1=Montag … 7=Sonntag
   meal: string; // 'fruehstueck' | 'vesper'
   slot: number;
   product_id?: number;
   product?: Product;
+  product_revision_id?: number;
+  product_revision?: number;
+  revision_changed: boolean; // Produkt seit der Planung geändert
   custom_text?: string;
   group_label?: string; // 'Krippe' | 'Kita' | 'Hort'
 }
@@ -43,9 +293,265 @@ export interface PlanEntry {
 export interface SpecialDay {
   id: number;
   week_plan_id: number;
-  day: number; // 1-5 (Mo-Fr)
-  type: string; // 'feiertag' | 'schliesstag'
+  day: number; // 1-7 (Mo-So)
+  type: string; // SpecialDayType
   label?: string;
+  meals: MealType[]; // betroffene Mahlzeiten, leer = ganzer Tag
+  groups: string[];  // betroffene Gruppen, leer = alle Gruppen
+}
+
+export interface DGERule {
+  id: number;
+  name: string;
+  food_groups: string[];
+  meal?: string;
+  min_count?: number; // je 5 Verpflegungstage
+  max_count?: number;
+  explanation: string;
+  enabled: boolean;
+}
+
+export interface DGEFinding {
+  rule_id: number;
+  rule_name: string;
+  passed: boolean;
+  count: number;
+  min?: number;
+  max?: number;
+  message: string;
+  explanation: string;
+}
+
+export interface DGEReport {
+  year: number;
+  week: number;
+  weeks: number;
+  operating_days: number;
+  missing_weeks: string[];
+  passed: boolean;
+  findings: DGEFinding[];
+}
+
+export interface Headcount {
+  group_label: string;
+  day: number; // 1-7 (Mo-So)
+  count: number;
+}
+
+export interface WeekHeadcount {
+  group_label: string;
+  day: number;
+  count: number;
+  override: boolean; // für diese Woche abweichend
+  meals: Record<string, number>; // nach Abzug der Sondertage
+}
+
+export interface PortionLine {
+  day: number;
+  meal: string;
+  product_id?: number;
+  name: string;
+  group_label?: string;
+  portions: number;
+  portion_size?: number; // g
+  quantity?: number; // g
+}
+
+export interface PortionTotal {
+  product_id?: number;
+  name: string;
+  portions: number;
+  portion_size?: number;
+  quantity?: number;
+}
+
+export interface PortionReport {
+  week_plan_id: number;
+  year: number;
+  week: number;
+  headcounts: WeekHeadcount[];
+  lines: PortionLine[];
+  totals: PortionTotal[];
+  missing_portion_size: string[];
+}
+
+export interface Supplier {
+  id: number;
+  name: string;
+  contact: string;
+  email: string;
+  phone: string;
+  customer_number: string;
+  delivery_days: number[]; // 1-7 (Mo-So), leer = nach Bedarf
+  lead_time_days: number;
+}
+
+export interface ProductSupplier {
+  product_id: number;
+  product_name: string;
+  supplier_id: number;
+  supplier_name: string;
+  article_number: string;
+  pack_size?: number; // g
+}
+
+export interface OrderLine {
+  product_id: number;
+  name: string;
+  article_number: string;
+  portions: number;
+  required?: number; // g
+  pack_size?: number; // g
+  packs?: number;
+}
+
+export interface SupplierOrder {
+  supplier: Supplier;
+  delivery_date: string; // YYYY-MM-DD
+  order_by: string;
+  overdue: boolean;
+  lines: OrderLine[];
+}
+
+export interface OrderProposal {
+  week_plan_id: number;
+  year: number;
+  week: number;
+  orders: SupplierOrder[];
+  unassigned: string[];
+}
+
+export interface ShoppingListOptions {
+  from: string; // YYYY-MM-DD
+  to: string;
+  stock?: Record<number, number>; // Vorrat je Produkt-ID in g, ohne Angabe Lagerbestand
+}
+
+export interface ShoppingItem {
+  product_id?: number;
+  name: string;
+  supplier: string;
+  category: string;
+  portions: number;
+  portion_size?: number;
+  required?: number; // g
+  stock: number; // g
+  to_buy?: number; // g
+}
+
+export interface ShoppingGroup {
+  supplier: string;
+  category: string;
+  items: ShoppingItem[];
+}
+
+export interface ShoppingList {
+  from: string;
+  to: string;
+  weeks: WeekRef[];
+  groups: ShoppingGroup[];
+  missing_portion_size: string[];
+}
+
+export interface ProductPrice {
+  id: number;
+  product_id: number;
+  price_per_kg: number; // Euro
+  valid_from: string; // YYYY-MM-DD
+  valid_to?: string; // letzter Gültigkeitstag, leer = unbefristet
+}
+
+export interface DailyBudget {
+  id: number;
+  amount: number; // Euro je Kind und Tag
+  valid_from: string; // YYYY-MM-DD
+  valid_to?: string; // letzter Gültigkeitstag, leer = unbefristet
+}
+
+export interface CostLine {
+  day: number;
+  meal: string;
+  product_id?: number;
+  name: string;
+  portions: number;
+  cost_per_portion?: number; // Euro
+  cost?: number;
+}
+
+export interface DayCost {
+  date: string; // YYYY-MM-DD
+  day: number;
+  children: number;
+  cost: number;
+  budget_per_child: number; // an diesem Tag gültiges Budget je Kind
+  budget: number;
+  cost_per_child?: number;
+}
+
+export interface WeekCostReport {
+  week_plan_id: number;
+  year: number;
+  week: number;
+  budgets: DailyBudget[]; // in der Woche gültige Budgets
+  days: DayCost[];
+  lines: CostLine[];
+  cost: number;
+  budget: number;
+  missing_prices: string[];
+}
+
+export interface MonthCostReport {
+  year: number;
+  month: number; // 1-12
+  budgets: DailyBudget[]; // im Monat gültige Budgets
+  days: DayCost[];
+  child_days: number;
+  cost: number;
+  budget: number;
+  difference: number; // Budget minus Kosten
+  missing_prices: string[];
+}
+
+export type StockMovementType = 'delivery' | 'consumption' | 'waste' | 'correction';
+
+export interface StockItem {
+  id: number;
+  product_id: number;
+  product_name: string;
+  min_stock: number; // g
+  location: string;
+  stock: number; // g
+  low: boolean; // unter Mindestbestand
+}
+
+export interface StockMovement {
+  id: number;
+  stock_item_id: number;
+  type: StockMovementType;
+  quantity: number; // Bestandsänderung in g, Abgänge negativ
+  created_at: string;
+  actor: string;
+  note: string;
+  week_plan_id?: number; // automatische Verbrauchsbuchung
+}
+
+export interface InventoryLine {
+  stock_item_id: number;
+  product_name: string;
+  opening: number;
+  deliveries: number;
+  consumption: number;
+  waste: number;
+  corrections: number;
+  closing: number;
+  min_stock: number;
+  low: boolean;
+}
+
+export interface InventoryReport {
+  from: string; // YYYY-MM-DD
+  to: string;
+  lines: InventoryLine[];
 }
 
 export interface UpdateInfo {
@@ -59,8 +565,8 @@ export interface UpdateInfo {
 // UI-spezifische Types
 export type MealType = 'fruehstueck' | 'vesper';
 export type GroupLabel = 'Krippe' | 'Kita' | 'Hort';
-export type SpecialDayType = 'feiertag' | 'schliesstag';
-export type WeekDay = 1 | 2 | 3 | 4 | 5; // Mo-Fr
+export type SpecialDayType = 'feiertag' | 'schliesstag' | 'ausflug' | 'teamtag' | 'brueckentag';
+export type WeekDay = 1 | 2 | 3 | 4 | 5 | 6 | 7; // Mo-So
 
 // Navigation
 export type NavRoute = 'wochenplan' | 'produkte' | 'info';
@@ -86,9 +592,14 @@ export const DAY_NAMES: Record<WeekDay, string> = {
   2: 'Dienstag', 
   3: 'Mittwoch',
   4: 'Donnerstag',
-  5: 'Freitag'
+  5: 'Freitag',
+  6: 'Samstag',
+  7: 'Sonntag'
 };
 
+// Betriebstage ohne eigene Einstellung
+export const DEFAULT_OPERATING_DAYS: WeekDay[] = [1, 2, 3, 4, 5];
+
 export const MEAL_NAMES: Record<MealType, string> = {
   fruehstueck: 'Frühstück',
   vesper: 'Vesper'
@@ -98,5 +609,18 @@ export const GROUP_LABELS: GroupLabel[] = ['Krippe', 'Kita', 'Hort'];
 
 export const SPECIAL_DAY_NAMES: Record<SpecialDayType, string> = {
   feiertag: 'Feiertag',
-  schliesstag: 'Schließtag'
-};
\ No newline at end of file
+  schliesstag: 'Schließtag',
+  ausflug: 'Ausflug',
+  teamtag: 'Teamtag',
+  brueckentag: 'Brückentag'
+};
+
+export const SPECIAL_DAY_DESCRIPTIONS: Record<SpecialDayType, string> = {
+  feiertag: 'Gesetzlicher oder betrieblicher Feiertag',
+  schliesstag: 'Kita ist geschlossen (z.B. Betriebsurlaub)',
+  ausflug: 'Ausflug, z.B. mit Lunchpaket statt der üblichen Mahlzeit',
+  teamtag: 'Teamtag oder Fortbildung, ggf. nur halbtags',
+  brueckentag: 'Brückentag zwischen Feiertag und Wochenende'
+};
+
+export const SPECIAL_DAY_TYPES: SpecialDayType[] = ['feiertag', 'schliesstag', 'ausflug', 'teamtag', 'brueckentag'];
\ No newline at end of file
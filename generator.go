@@ -0,0 +1,318 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+
+	"speiseplan/isoweek"
+)
+
+// VORSCHLÄGE
+
+// GeneratePlanProposal schlägt einen Wochenplan aus dem Produktkatalog vor, ohne ihn zu speichern.
+// Berücksichtigt werden Sondertage, Wiederholungsabstand, Kategorienmischung pro Tag,
+// Gruppenprofile und Vorlieben aus der bisherigen Verwendung. Gleicher Seed und gleicher
+// Datenbestand ergeben denselben Vorschlag.
+func (a *App) GeneratePlanProposal(year int, week int, opts GeneratorOptions) (*WeekPlan, error) {
+	meals := opts.Meals
+	if len(meals) == 0 {
+		meals = planMeals
+	}
+	perMeal := opts.EntriesPerMeal
+	if perMeal <= 0 {
+		perMeal = 1
+	}
+	historyWeeks := opts.HistoryWeeks
+	if historyWeeks <= 0 {
+		historyWeeks = 8
+	}
+	if minWeeks := opts.NoRepeatDays/7 + 1; historyWeeks < minWeeks {
+		historyWeeks = minWeeks
+	}
+
+	calendar, err := planDays(year, week)
+	if err != nil {
+		return nil, err
+	}
+	proposal := &WeekPlan{Year: year, Week: week, Entries: []PlanEntry{}, SpecialDays: []SpecialDay{}, Days: calendar}
+	existing, err := a.GetWeekPlan(year, week)
+	if err != nil && !isNotFound(err) {
+		return nil, err
+	}
+	if existing != nil {
+		proposal.ID = existing.ID
+		proposal.SpecialDays = existing.SpecialDays
+	}
+
+	products, err := a.FilterProducts(opts.Filter)
+	if err != nil {
+		return nil, err
+	}
+	if len(products) == 0 {
+		return nil, fmt.Errorf("no products match the generator filter")
+	}
+
+	// Erlaubte Produkte je Profil
+	profileAllowed := make([]map[int]bool, len(opts.Profiles))
+	for i, profile := range opts.Profiles {
+		allowed, err := a.FilterProducts(profile.Filter)
+		if err != nil {
+			return nil, err
+		}
+		profileAllowed[i] = map[int]bool{}
+		for _, p := range allowed {
+			profileAllowed[i][p.ID] = true
+		}
+	}
+
+	// Bekannte Gruppen aus den Kinderzahlen und den Profilen
+	pattern, err := a.GetHeadcountPattern()
+	if err != nil {
+		return nil, err
+	}
+	var groups []string
+	for _, h := range pattern {
+		if !containsString(groups, h.GroupLabel) {
+			groups = append(groups, h.GroupLabel)
+		}
+	}
+	for _, profile := range opts.Profiles {
+		if !containsString(groups, profile.GroupLabel) {
+			groups = append(groups, profile.GroupLabel)
+		}
+	}
+
+	resolver, err := newCategoryColorResolver()
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := loadUsageHistory(year, week, historyWeeks)
+	if err != nil {
+		return nil, err
+	}
+
+	// Vorlieben: wie oft wurde ein Produkt bisher zu dieser Mahlzeit geplant
+	usage := map[string]int{}
+	lastUsed := map[int]int{} // Produkt → letzter Tag relativ zum Montag der Zielwoche
+	for _, h := range history {
+		usage[fmt.Sprintf("%d/%s", h.productID, h.meal)]++
+		if last, ok := lastUsed[h.productID]; !ok || h.offset > last {
+			lastUsed[h.productID] = h.offset
+		}
+	}
+
+	days, err := operatingDays()
+	if err != nil {
+		return nil, err
+	}
+
+	rng := rand.New(rand.NewSource(opts.Seed))
+
+	for _, day := range days {
+		if closingSpecial(proposal.SpecialDays, day) != nil {
+			continue
+		}
+		offset := day - 1
+		categoriesToday := map[int]int{}
+
+		for _, meal := range meals {
+			if blockingSpecial(proposal.SpecialDays, day, meal) != nil {
+				continue
+			}
+			inMeal := map[int]bool{}
+			slot := 0
+
+			pick := func(allowed map[int]bool, strict bool) *Product {
+				var candidates []*Product
+				var weights []float64
+				for i := range products {
+					p := &products[i]
+					if inMeal[p.ID] || (allowed != nil && !allowed[p.ID]) {
+						continue
+					}
+					if last, ok := lastUsed[p.ID]; strict && ok && opts.NoRepeatDays > 0 && offset-last < opts.NoRepeatDays {
+						continue
+					}
+					weight := 1 + math.Log1p(float64(usage[fmt.Sprintf("%d/%s", p.ID, meal)]))
+					if root := rootCategoryID(resolver, p); root != 0 {
+						weight /= float64(1 + 2*categoriesToday[root])
+					}
+					candidates = append(candidates, p)
+					weights = append(weights, weight)
+				}
+				if len(candidates) == 0 {
+					return nil
+				}
+				return candidates[weightedIndex(rng, weights)]
+			}
+
+			add := func(p *Product, groupLabels []*string) {
+				inMeal[p.ID] = true
+				if len(groupLabels) == 0 {
+					return
+				}
+				for _, groupLabel := range groupLabels {
+					productID := p.ID
+					proposal.Entries = append(proposal.Entries, PlanEntry{
+						WeekPlanID: proposal.ID,
+						Day:        day,
+						Meal:       meal,
+						Slot:       slot,
+						ProductID:  &productID,
+						Product:    p,
+						GroupLabel: groupLabel,
+					})
+					slot++
+				}
+				lastUsed[p.ID] = offset
+				if root := rootCategoryID(resolver, p); root != 0 {
+					categoriesToday[root]++
+				}
+			}
+
+			for n := 0; n < perMeal; n++ {
+				// Wiederholungsabstand nur aufgeben, wenn sonst nichts mehr passt
+				p := pick(nil, true)
+				if p == nil {
+					p = pick(nil, false)
+				}
+				if p == nil {
+					break
+				}
+
+				// Passt p nicht zu einem Profil, gilt es nur noch für die übrigen Gruppen
+				restricted := map[string]bool{}
+				for i, profile := range opts.Profiles {
+					if !profileAllowed[i][p.ID] {
+						restricted[profile.GroupLabel] = true
+					}
+				}
+				if len(restricted) == 0 {
+					add(p, []*string{nil})
+				} else {
+					var labels []*string
+					for _, group := range groups {
+						if !restricted[group] {
+							labels = append(labels, &group)
+						}
+					}
+					add(p, labels)
+				}
+
+				for i, profile := range opts.Profiles {
+					if profileAllowed[i][p.ID] || entryBlocked(proposal.SpecialDays, day, meal, &profile.GroupLabel) {
+						continue
+					}
+					alt := pick(profileAllowed[i], true)
+					if alt == nil {
+						alt = pick(profileAllowed[i], false)
+					}
+					if alt != nil {
+						label := profile.GroupLabel
+						add(alt, []*string{&label})
+					}
+				}
+			}
+		}
+	}
+
+	return proposal, nil
+}
+
+// ApplyPlanProposal übernimmt einen (ggf. bearbeiteten) Vorschlag in seine Kalenderwoche.
+// Die Konfliktmodi entsprechen CopyWeekPlanWithMode. Die Sondertage der Zielwoche bleiben
+// erhalten; Einträge, die wegen eines davon entfallen, werden nicht übernommen.
+func (a *App) ApplyPlanProposal(proposal WeekPlan, mode string) (*CopyResult, error) {
+	mode, err := validateConflictMode(mode)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range proposal.Entries {
+		if err := validateOperatingDay(e.Day); err != nil {
+			return nil, err
+		}
+		if err := validatePlanMeal(e.Meal); err != nil {
+			return nil, err
+		}
+		if e.ProductID == nil && (e.CustomText == nil || strings.TrimSpace(*e.CustomText) == "") {
+			return nil, fmt.Errorf("proposal entry on day %d (%s) has neither product nor text", e.Day, e.Meal)
+		}
+	}
+	return a.copyIntoWeek(proposal.Entries, nil, false, proposal.Year, proposal.Week, mode)
+}
+
+// HILFSFUNKTIONEN
+
+// usageRecord ist eine frühere Verwendung eines Produkts
+type usageRecord struct {
+	productID int
+	meal      string
+	offset    int // Tag relativ zum Montag der Zielwoche (negativ = Vergangenheit)
+}
+
+// loadUsageHistory lädt die Planeinträge der weeks Wochen vor year/week
+func loadUsageHistory(year int, week int, weeks int) ([]usageRecord, error) {
+	var history []usageRecord
+	for i := 1; i <= weeks; i++ {
+		y, w := isoweek.AddWeeks(year, week, -i)
+
+		var rows []struct {
+			ProductID int    `db:"product_id"`
+			Meal      string `db:"meal"`
+			Day       int    `db:"day"`
+		}
+		err := db.Select(&rows, `
+			SELECT pe.product_id, pe.meal, pe.day
+			FROM plan_entries pe
+			JOIN week_plans wp ON wp.id = pe.week_plan_id
+			WHERE wp.year = ? AND wp.week = ? AND pe.product_id IS NOT NULL
+		`, y, w)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load usage history: %w", err)
+		}
+
+		for _, r := range rows {
+			history = append(history, usageRecord{productID: r.ProductID, meal: r.Meal, offset: -7*i + r.Day - 1})
+		}
+	}
+	return history, nil
+}
+
+// rootCategoryID gibt die oberste Kategorie der ersten Kategorie eines Produkts zurück (0 = keine)
+func rootCategoryID(resolver categoryColorResolver, product *Product) int {
+	if len(product.Categories) == 0 {
+		return 0
+	}
+
+	id := product.Categories[0].ID
+	seen := map[int]bool{}
+	for !seen[id] {
+		seen[id] = true
+		c, ok := resolver[id]
+		if !ok || c.ParentID == nil {
+			break
+		}
+		id = *c.ParentID
+	}
+	return id
+}
+
+// weightedIndex wählt zufällig einen Index mit Wahrscheinlichkeit proportional zum Gewicht
+func weightedIndex(rng *rand.Rand, weights []float64) int {
+	total := 0.0
+	for _, w := range weights {
+		total += w
+	}
+
+	r := rng.Float64() * total
+	for i, w := range weights {
+		if r < w {
+			return i
+		}
+		r -= w
+	}
+	return len(weights) - 1
+}
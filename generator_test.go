@@ -0,0 +1,236 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+// setupGeneratorTest legt eine leere Datenbank im Temp-Verzeichnis an und füllt den Katalog
+func setupGeneratorTest(t *testing.T) *App {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	if err := InitDatabase(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { CloseDatabase() })
+
+	a := NewApp()
+	for i := 1; i <= 20; i++ {
+		if _, err := a.CreateProduct(fmt.Sprintf("Produkt %d", i), false, nil, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return a
+}
+
+// proposalKeys beschreibt die Einträge eines Vorschlags in vergleichbarer Form
+func proposalKeys(plan *WeekPlan) []string {
+	keys := make([]string, 0, len(plan.Entries))
+	for _, e := range plan.Entries {
+		group := ""
+		if e.GroupLabel != nil {
+			group = *e.GroupLabel
+		}
+		keys = append(keys, fmt.Sprintf("%d/%s/%d/%d/%s", e.Day, e.Meal, e.Slot, *e.ProductID, group))
+	}
+	return keys
+}
+
+func equalKeys(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestGeneratePlanProposalSameSeed(t *testing.T) {
+	a := setupGeneratorTest(t)
+	opts := GeneratorOptions{Seed: 42, EntriesPerMeal: 2}
+
+	first, err := a.GeneratePlanProposal(2026, 10, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := a.GeneratePlanProposal(2026, 10, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(first.Entries) == 0 {
+		t.Fatal("proposal has no entries")
+	}
+	if got, want := proposalKeys(second), proposalKeys(first); !equalKeys(got, want) {
+		t.Errorf("same seed produced different proposals:\n%v\n%v", want, got)
+	}
+}
+
+func TestGeneratePlanProposalDifferentSeed(t *testing.T) {
+	a := setupGeneratorTest(t)
+
+	first, err := a.GeneratePlanProposal(2026, 10, GeneratorOptions{Seed: 1, EntriesPerMeal: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := a.GeneratePlanProposal(2026, 10, GeneratorOptions{Seed: 2, EntriesPerMeal: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if equalKeys(proposalKeys(first), proposalKeys(second)) {
+		t.Errorf("different seeds produced identical proposals: %v", proposalKeys(first))
+	}
+}
+
+func TestGeneratePlanProposalNoRepeatDays(t *testing.T) {
+	tests := []struct {
+		name         string
+		seed         int64
+		noRepeatDays int
+	}{
+		{"zwei Tage", 1, 2},
+		{"drei Tage", 2, 3},
+		{"ganze Woche", 3, 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := setupGeneratorTest(t)
+			plan, err := a.GeneratePlanProposal(2026, 10, GeneratorOptions{Seed: tt.seed, EntriesPerMeal: 2, NoRepeatDays: tt.noRepeatDays})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(plan.Entries) == 0 {
+				t.Fatal("proposal has no entries")
+			}
+
+			lastDay := map[int]int{}
+			for _, e := range plan.Entries {
+				if last, ok := lastDay[*e.ProductID]; ok && e.Day-last < tt.noRepeatDays {
+					t.Errorf("product %d planned on day %d and again on day %d", *e.ProductID, last, e.Day)
+				}
+				lastDay[*e.ProductID] = e.Day
+			}
+		})
+	}
+}
+
+func TestGeneratePlanProposalCategoryBalance(t *testing.T) {
+	tests := []struct {
+		name string
+		seed int64
+	}{
+		{"Seed 1", 1},
+		{"Seed 2", 2},
+		{"Seed 3", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := setupGeneratorTest(t)
+			products, err := a.GetProducts()
+			if err != nil {
+				t.Fatal(err)
+			}
+			first, err := a.CreateCategory("Testkategorie A", nil, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			second, err := a.CreateCategory("Testkategorie B", nil, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			categoryOf := map[int]int{}
+			for i, p := range products {
+				categoryID := first.ID
+				if i%2 == 1 {
+					categoryID = second.ID
+				}
+				if _, err := a.SetProductCategories(p.ID, []int{categoryID}); err != nil {
+					t.Fatal(err)
+				}
+				categoryOf[p.ID] = categoryID
+			}
+
+			plan, err := a.GeneratePlanProposal(2026, 10, GeneratorOptions{Seed: tt.seed, EntriesPerMeal: 2})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			perDay := map[int]map[int]bool{}
+			for _, e := range plan.Entries {
+				if perDay[e.Day] == nil {
+					perDay[e.Day] = map[int]bool{}
+				}
+				perDay[e.Day][categoryOf[*e.ProductID]] = true
+			}
+			if len(perDay) == 0 {
+				t.Fatal("proposal has no entries")
+			}
+			for day, categories := range perDay {
+				if len(categories) < 2 {
+					t.Errorf("day %d uses only one category", day)
+				}
+			}
+		})
+	}
+}
+
+func TestGeneratePlanProposalProfileAlternatives(t *testing.T) {
+	tests := []struct {
+		name   string
+		groups []string // Gruppen mit hinterlegten Kinderzahlen
+	}{
+		{"mit weiteren Gruppen", []string{"Krippe", "Hort"}},
+		{"nur eingeschränkte Gruppe", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := setupGeneratorTest(t)
+			for i := 1; i <= 20; i++ {
+				if _, err := a.CreateProduct(fmt.Sprintf("Nussprodukt %d", i), false, []string{"h"}, nil); err != nil {
+					t.Fatal(err)
+				}
+			}
+			var pattern []Headcount
+			for _, group := range tt.groups {
+				pattern = append(pattern, Headcount{GroupLabel: group, Day: 1, Count: 10})
+			}
+			if _, err := a.SetHeadcountPattern(pattern); err != nil {
+				t.Fatal(err)
+			}
+
+			plan, err := a.GeneratePlanProposal(2026, 10, GeneratorOptions{
+				Seed:           7,
+				EntriesPerMeal: 2,
+				Profiles:       []GeneratorProfile{{GroupLabel: "Krippe", Filter: ProductFilter{ExcludeAllergens: []string{"h"}}}},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			alternatives := 0
+			for _, e := range plan.Entries {
+				group := ""
+				if e.GroupLabel != nil {
+					group = *e.GroupLabel
+				}
+				restricted := false
+				for _, allergen := range e.Product.Allergens {
+					restricted = restricted || allergen.ID == "h"
+				}
+				if restricted && (group == "" || group == "Krippe") {
+					t.Errorf("day %d %s: %s is served to Krippe", e.Day, e.Meal, e.Product.Name)
+				}
+				if group == "Krippe" {
+					alternatives++
+				}
+			}
+			if alternatives == 0 {
+				t.Error("proposal has no alternatives for Krippe")
+			}
+		})
+	}
+}
@@ -0,0 +1,495 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// KINDERZAHLEN
+
+// GetHeadcountPattern gibt das Wochenmuster der erwarteten Kinderzahlen je Gruppe und Wochentag zurück
+func (a *App) GetHeadcountPattern() ([]Headcount, error) {
+	counts := []Headcount{}
+	err := db.Select(&counts, "SELECT group_label, day, count FROM headcount_defaults ORDER BY group_label, day")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get headcount pattern: %w", err)
+	}
+	return counts, nil
+}
+
+// SetHeadcountPattern ersetzt das Wochenmuster der Kinderzahlen. Es gilt für alle Wochen
+// ohne eigene Abweichung (SetWeekHeadcount).
+func (a *App) SetHeadcountPattern(counts []Headcount) ([]Headcount, error) {
+	seen := map[string]bool{}
+	for i := range counts {
+		c := &counts[i]
+		c.GroupLabel = strings.TrimSpace(c.GroupLabel)
+		if err := validateHeadcount(c.GroupLabel, c.Day, c.Count); err != nil {
+			return nil, err
+		}
+		key := fmt.Sprintf("%s/%d", c.GroupLabel, c.Day)
+		if seen[key] {
+			return nil, fmt.Errorf("duplicate headcount for group %q on day %d", c.GroupLabel, c.Day)
+		}
+		seen[key] = true
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM headcount_defaults"); err != nil {
+		return nil, fmt.Errorf("failed to clear headcount pattern: %w", err)
+	}
+	for _, c := range counts {
+		_, err := tx.Exec("INSERT INTO headcount_defaults (group_label, day, count) VALUES (?, ?, ?)", c.GroupLabel, c.Day, c.Count)
+		if err != nil {
+			return nil, fmt.Errorf("failed to save headcount: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return a.GetHeadcountPattern()
+}
+
+// GetWeekHeadcounts gibt die erwarteten Kinderzahlen eines Wochenplans je Betriebstag und
+// Gruppe zurück, je Mahlzeit bereits um Sondertage reduziert
+func (a *App) GetWeekHeadcounts(weekPlanID int) ([]WeekHeadcount, error) {
+	plan, err := a.getWeekPlanByID(db, weekPlanID)
+	if err != nil {
+		return nil, err
+	}
+	return weekHeadcounts(plan)
+}
+
+// SetWeekHeadcount trägt für einen Tag eines Wochenplans eine vom Wochenmuster abweichende
+// Kinderzahl ein (z.B. Krankheitswelle). count == nil entfernt die Abweichung wieder.
+// Kinderzahlen dürfen auch in freigegebenen Plänen noch angepasst werden.
+func (a *App) SetWeekHeadcount(weekPlanID int, day int, groupLabel string, count *int) error {
+	groupLabel = strings.TrimSpace(groupLabel)
+	value := 0
+	if count != nil {
+		value = *count
+	}
+	if err := validateHeadcount(groupLabel, day, value); err != nil {
+		return err
+	}
+	if _, err := a.getWeekPlanByID(db, weekPlanID); err != nil {
+		return err
+	}
+
+	if count == nil {
+		_, err := db.Exec("DELETE FROM headcounts WHERE week_plan_id = ? AND group_label = ? AND day = ?", weekPlanID, groupLabel, day)
+		if err != nil {
+			return fmt.Errorf("failed to remove headcount: %w", err)
+		}
+		return nil
+	}
+
+	_, err := db.Exec(`
+		INSERT INTO headcounts (week_plan_id, group_label, day, count) VALUES (?, ?, ?, ?)
+		ON CONFLICT(week_plan_id, group_label, day) DO UPDATE SET count = excluded.count
+	`, weekPlanID, groupLabel, day, *count)
+	if err != nil {
+		return fmt.Errorf("failed to save headcount: %w", err)
+	}
+	return nil
+}
+
+// PORTIONEN
+
+// SetProductPortionSize setzt die Portionsgröße eines Produkts in g (nil = unbekannt),
+// ohne die Nährwertangaben zu verändern
+func (a *App) SetProductPortionSize(productID int, portionSize *float64) (*Product, error) {
+	if portionSize != nil && *portionSize <= 0 {
+		return nil, fmt.Errorf("portion size must be positive")
+	}
+
+	before, err := a.GetProduct(productID)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("UPDATE products SET portion_size = ? WHERE id = ?", portionSize, productID); err != nil {
+		return nil, fmt.Errorf("failed to update portion size: %w", err)
+	}
+
+	after, err := a.auditProductUpdate(tx, before)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return after, nil
+}
+
+// GetPortionReport berechnet den Bedarf eines Wochenplans: Portionen und Menge je Produkt,
+// Tag und Mahlzeit sowie die Wochensumme je Produkt. Einträge mit Gruppe zählen nur deren
+// Kinder, Einträge ohne Gruppe alle Gruppen; Sondertage verringern die Zahl entsprechend.
+func (a *App) GetPortionReport(weekPlanID int) (*PortionReport, error) {
+	plan, err := a.getWeekPlanByID(db, weekPlanID)
+	if err != nil {
+		return nil, err
+	}
+	headcounts, err := weekHeadcounts(plan)
+	if err != nil {
+		return nil, err
+	}
+	return buildPortionReport(plan, headcounts), nil
+}
+
+// EXPORT
+
+// ExportPortionsCSV exportiert den Portionsbedarf eines Wochenplans als CSV (Semikolon, für Excel)
+func (a *App) ExportPortionsCSV(weekPlanID int, outputPath string) error {
+	report, err := a.GetPortionReport(weekPlanID)
+	if err != nil {
+		return err
+	}
+
+	f, w, err := newExcelCSV(outputPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := w.Write([]string{"Tag", "Mahlzeit", "Produkt", "Gruppe", "Portionen", "Portionsgröße (g)", "Menge (g)"}); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+	for _, l := range report.Lines {
+		group := "alle"
+		if l.GroupLabel != nil {
+			group = *l.GroupLabel
+		}
+		record := []string{
+			weekdayName(l.Day),
+			planMealNames[l.Meal],
+			l.Name,
+			group,
+			fmt.Sprint(l.Portions),
+			formatQuantity(l.PortionSize),
+			formatQuantity(l.Quantity),
+		}
+		if err := w.Write(record); err != nil {
+			return fmt.Errorf("failed to write CSV record: %w", err)
+		}
+	}
+	for _, t := range report.Totals {
+		record := []string{"Woche", "", t.Name, "", fmt.Sprint(t.Portions), formatQuantity(t.PortionSize), formatQuantity(t.Quantity)}
+		if err := w.Write(record); err != nil {
+			return fmt.Errorf("failed to write CSV record: %w", err)
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("failed to write CSV file: %w", err)
+	}
+	return f.Close()
+}
+
+// ExportPortionsPDF exportiert den Portionsbedarf eines Wochenplans als Küchenliste (Hochformat A4)
+func (a *App) ExportPortionsPDF(weekPlanID int, outputPath string) error {
+	report, err := a.GetPortionReport(weekPlanID)
+	if err != nil {
+		return err
+	}
+
+	pdf := newReportPDF("P")
+	pdf.AddPage()
+
+	pageW, _ := pdf.GetPageSize()
+	marginX := 10.0
+	usableW := pageW - 2*marginX
+
+	pdf.SetFont("DejaVu", "B", 14)
+	pdf.CellFormat(usableW, 8, fmt.Sprintf("Portionsplanung KW %d / %d", report.Week, report.Year), "", 1, "L", false, 0, "")
+	pdf.Ln(2)
+
+	// Kinderzahlen je Tag und Gruppe
+	pdf.SetFont("DejaVu", "B", 10)
+	pdf.CellFormat(usableW, 6, "Erwartete Kinderzahlen", "", 1, "L", false, 0, "")
+	var groups []string
+	var days []int
+	counts := map[string]int{}
+	for _, h := range report.Headcounts {
+		if !containsString(groups, h.GroupLabel) {
+			groups = append(groups, h.GroupLabel)
+		}
+		if !containsInt(days, h.Day) {
+			days = append(days, h.Day)
+		}
+		counts[fmt.Sprintf("%s/%d", h.GroupLabel, h.Day)] = h.Count
+	}
+	if len(groups) == 0 {
+		pdf.SetFont("DejaVu", "", 9)
+		pdf.CellFormat(usableW, 6, "Keine Kinderzahlen hinterlegt.", "", 1, "L", false, 0, "")
+	} else {
+		colW := (usableW - 30) / float64(len(days))
+		pdf.SetFont("DejaVu", "B", 8)
+		pdf.SetFillColor(220, 220, 220)
+		pdf.CellFormat(30, 6, "Gruppe", "1", 0, "L", true, 0, "")
+		for _, day := range days {
+			pdf.CellFormat(colW, 6, weekdayName(day), "1", 0, "C", true, 0, "")
+		}
+		pdf.Ln(-1)
+		pdf.SetFont("DejaVu", "", 8)
+		for _, group := range groups {
+			pdf.CellFormat(30, 5, group, "1", 0, "L", false, 0, "")
+			for _, day := range days {
+				pdf.CellFormat(colW, 5, fmt.Sprint(counts[fmt.Sprintf("%s/%d", group, day)]), "1", 0, "C", false, 0, "")
+			}
+			pdf.Ln(-1)
+		}
+	}
+	pdf.Ln(4)
+
+	// Bedarf je Tag und Mahlzeit
+	widths := []float64{24, 22, usableW - 124, 22, 18, 18, 20}
+	headers := []string{"Tag", "Mahlzeit", "Produkt", "Gruppe", "Portionen", "Portion (g)", "Menge (g)"}
+	drawHeader := func() {
+		pdf.SetFont("DejaVu", "B", 8)
+		pdf.SetFillColor(220, 220, 220)
+		for i, h := range headers {
+			pdf.CellFormat(widths[i], 6, h, "1", 0, "L", true, 0, "")
+		}
+		pdf.Ln(-1)
+		pdf.SetFont("DejaVu", "", 8)
+	}
+	pdf.SetFont("DejaVu", "B", 10)
+	pdf.CellFormat(usableW, 6, "Bedarf je Tag", "", 1, "L", false, 0, "")
+	drawHeader()
+	for _, l := range report.Lines {
+		group := "alle"
+		if l.GroupLabel != nil {
+			group = *l.GroupLabel
+		}
+		cells := []string{weekdayName(l.Day), planMealNames[l.Meal], l.Name, group, fmt.Sprint(l.Portions), formatQuantity(l.PortionSize), formatQuantity(l.Quantity)}
+		for i, text := range cells {
+			pdf.CellFormat(widths[i], 5, text, "1", 0, "L", false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+	if len(report.Lines) == 0 {
+		pdf.CellFormat(usableW, 6, "Keine Einträge im Wochenplan.", "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(4)
+
+	// Wochensumme je Produkt
+	pdf.SetFont("DejaVu", "B", 10)
+	pdf.CellFormat(usableW, 6, "Wochensumme", "", 1, "L", false, 0, "")
+	pdf.SetFont("DejaVu", "B", 8)
+	pdf.CellFormat(usableW-60, 6, "Produkt", "1", 0, "L", true, 0, "")
+	pdf.CellFormat(20, 6, "Portionen", "1", 0, "L", true, 0, "")
+	pdf.CellFormat(20, 6, "Portion (g)", "1", 0, "L", true, 0, "")
+	pdf.CellFormat(20, 6, "Menge (g)", "1", 1, "L", true, 0, "")
+	pdf.SetFont("DejaVu", "", 8)
+	for _, t := range report.Totals {
+		pdf.CellFormat(usableW-60, 5, t.Name, "1", 0, "L", false, 0, "")
+		pdf.CellFormat(20, 5, fmt.Sprint(t.Portions), "1", 0, "L", false, 0, "")
+		pdf.CellFormat(20, 5, formatQuantity(t.PortionSize), "1", 0, "L", false, 0, "")
+		pdf.CellFormat(20, 5, formatQuantity(t.Quantity), "1", 1, "L", false, 0, "")
+	}
+
+	if len(report.MissingPortionSize) > 0 {
+		pdf.Ln(3)
+		pdf.SetFont("DejaVu", "", 8)
+		pdf.MultiCell(usableW, 4, "Ohne Portionsgröße (Menge nicht berechnet): "+strings.Join(report.MissingPortionSize, ", "), "", "L", false)
+	}
+
+	return pdf.OutputFileAndClose(outputPath)
+}
+
+// HILFSFUNKTIONEN
+
+// validateHeadcount prüft Gruppe, Wochentag und Kinderzahl
+func validateHeadcount(groupLabel string, day int, count int) error {
+	if groupLabel == "" {
+		return fmt.Errorf("group label is required")
+	}
+	if err := validatePlanDay(day); err != nil {
+		return err
+	}
+	if count < 0 {
+		return fmt.Errorf("headcount must not be negative")
+	}
+	return nil
+}
+
+// weekHeadcounts ermittelt die Kinderzahlen eines Plans aus Wochenmuster und Abweichungen
+func weekHeadcounts(plan *WeekPlan) ([]WeekHeadcount, error) {
+	var pattern []Headcount
+	if err := db.Select(&pattern, "SELECT group_label, day, count FROM headcount_defaults"); err != nil {
+		return nil, fmt.Errorf("failed to get headcount pattern: %w", err)
+	}
+	var overrides []Headcount
+	if err := db.Select(&overrides, "SELECT group_label, day, count FROM headcounts WHERE week_plan_id = ?", plan.ID); err != nil {
+		return nil, fmt.Errorf("failed to get week headcounts: %w", err)
+	}
+
+	counts := map[string]int{}
+	overridden := map[string]bool{}
+	var groups []string
+	for _, list := range [][]Headcount{pattern, overrides} {
+		for _, h := range list {
+			if !containsString(groups, h.GroupLabel) {
+				groups = append(groups, h.GroupLabel)
+			}
+		}
+	}
+	for _, h := range pattern {
+		counts[fmt.Sprintf("%s/%d", h.GroupLabel, h.Day)] = h.Count
+	}
+	for _, h := range overrides {
+		key := fmt.Sprintf("%s/%d", h.GroupLabel, h.Day)
+		counts[key] = h.Count
+		overridden[key] = true
+	}
+	sort.Strings(groups)
+
+	days, err := operatingDays()
+	if err != nil {
+		return nil, err
+	}
+
+	result := []WeekHeadcount{}
+	for _, day := range days {
+		for _, group := range groups {
+			key := fmt.Sprintf("%s/%d", group, day)
+			h := WeekHeadcount{GroupLabel: group, Day: day, Count: counts[key], Override: overridden[key], Meals: map[string]int{}}
+			for _, meal := range planMeals {
+				if entryBlocked(plan.SpecialDays, day, meal, &group) {
+					h.Meals[meal] = 0
+				} else {
+					h.Meals[meal] = h.Count
+				}
+			}
+			result = append(result, h)
+		}
+	}
+	return result, nil
+}
+
+// buildPortionReport berechnet Portionen und Mengen der Planeinträge an Betriebstagen
+func buildPortionReport(plan *WeekPlan, headcounts []WeekHeadcount) *PortionReport {
+	report := &PortionReport{
+		WeekPlanID:         plan.ID,
+		Year:               plan.Year,
+		Week:               plan.Week,
+		Headcounts:         headcounts,
+		Lines:              []PortionLine{},
+		Totals:             []PortionTotal{},
+		MissingPortionSize: []string{},
+	}
+
+	totals := map[string]*PortionTotal{}
+	var order []string
+	for _, e := range plan.Entries {
+		portions := 0
+		operating := false
+		for _, h := range headcounts {
+			if h.Day != e.Day {
+				continue
+			}
+			operating = true
+			if e.GroupLabel == nil || *e.GroupLabel == h.GroupLabel {
+				portions += h.Meals[e.Meal]
+			}
+		}
+		if !operating {
+			continue
+		}
+
+		line := PortionLine{Day: e.Day, Meal: e.Meal, ProductID: e.ProductID, GroupLabel: e.GroupLabel, Portions: portions}
+		if e.Product != nil {
+			line.Name = e.Product.Name
+			line.PortionSize = e.Product.PortionSize
+		} else if e.CustomText != nil {
+			line.Name = *e.CustomText
+		}
+		if line.PortionSize != nil {
+			quantity := *line.PortionSize * float64(portions)
+			line.Quantity = &quantity
+		}
+		report.Lines = append(report.Lines, line)
+
+		key := "text:" + line.Name
+		if line.ProductID != nil {
+			key = fmt.Sprintf("product:%d", *line.ProductID)
+		}
+		total, ok := totals[key]
+		if !ok {
+			total = &PortionTotal{ProductID: line.ProductID, Name: line.Name, PortionSize: line.PortionSize}
+			totals[key] = total
+			order = append(order, key)
+		}
+		total.Portions += portions
+		if line.Quantity != nil {
+			sum := *line.Quantity
+			if total.Quantity != nil {
+				sum += *total.Quantity
+			}
+			total.Quantity = &sum
+		}
+	}
+
+	sort.SliceStable(report.Lines, func(i, j int) bool {
+		a, b := report.Lines[i], report.Lines[j]
+		if a.Day != b.Day {
+			return a.Day < b.Day
+		}
+		return a.Meal < b.Meal
+	})
+	for _, key := range order {
+		total := totals[key]
+		report.Totals = append(report.Totals, *total)
+		if total.ProductID != nil && total.PortionSize == nil {
+			report.MissingPortionSize = append(report.MissingPortionSize, total.Name)
+		}
+	}
+	sort.Slice(report.Totals, func(i, j int) bool {
+		return strings.ToLower(report.Totals[i].Name) < strings.ToLower(report.Totals[j].Name)
+	})
+	sort.Strings(report.MissingPortionSize)
+	return report
+}
+
+// formatQuantity formatiert eine Menge in g ohne Nachkommastellen („–“ = unbekannt)
+func formatQuantity(v *float64) string {
+	if v == nil {
+		return "–"
+	}
+	return fmt.Sprintf("%.0f", *v)
+}
+
+// containsString prüft, ob ein Wert in der Liste vorkommt
+func containsString(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// containsInt prüft, ob ein Wert in der Liste vorkommt
+func containsInt(list []int, value int) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,385 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"speiseplan/isoweek"
+)
+
+// Arten von Lagerbewegungen
+const (
+	stockMovementDelivery    = "delivery"    // Wareneingang
+	stockMovementConsumption = "consumption" // Verbrauch
+	stockMovementWaste       = "waste"       // Verderb/Entsorgung
+	stockMovementCorrection  = "correction"  // Inventurkorrektur
+)
+
+// stockMovementNames sind die deutschen Bezeichnungen der Bewegungsarten
+var stockMovementNames = map[string]string{
+	stockMovementDelivery:    "Wareneingang",
+	stockMovementConsumption: "Verbrauch",
+	stockMovementWaste:       "Verderb",
+	stockMovementCorrection:  "Korrektur",
+}
+
+// stockItemQuery lädt Lagerartikel mit Produktname und aktuellem Bestand
+const stockItemQuery = `
+	SELECT s.id, s.product_id, p.name AS product_name, s.min_stock, s.location,
+		COALESCE((SELECT SUM(m.quantity) FROM stock_movements m WHERE m.stock_item_id = s.id), 0) AS stock
+	FROM stock_items s
+	JOIN products p ON p.id = s.product_id
+`
+
+// LAGERARTIKEL
+
+// GetStockItems gibt alle Lagerartikel mit aktuellem Bestand zurück
+func (a *App) GetStockItems() ([]StockItem, error) {
+	items := []StockItem{}
+	if err := db.Select(&items, stockItemQuery+" ORDER BY p.name COLLATE NOCASE"); err != nil {
+		return nil, fmt.Errorf("failed to get stock items: %w", err)
+	}
+	for i := range items {
+		items[i].Low = items[i].Stock < items[i].MinStock
+	}
+	return items, nil
+}
+
+// SaveStockItem legt einen Lagerartikel an (ID 0) oder ändert Mindestbestand und Lagerort.
+// Je Produkt gibt es höchstens einen Lagerartikel.
+func (a *App) SaveStockItem(item StockItem) (*StockItem, error) {
+	if item.MinStock < 0 {
+		return nil, fmt.Errorf("minimum stock must not be negative")
+	}
+	item.Location = strings.TrimSpace(item.Location)
+
+	if item.ID == 0 {
+		if _, err := a.GetProduct(item.ProductID); err != nil {
+			return nil, err
+		}
+		res, err := db.Exec("INSERT INTO stock_items (product_id, min_stock, location) VALUES (?, ?, ?)",
+			item.ProductID, item.MinStock, item.Location)
+		if err != nil {
+			if strings.Contains(err.Error(), "UNIQUE") {
+				return nil, fmt.Errorf("product %d already has a stock item", item.ProductID)
+			}
+			return nil, fmt.Errorf("failed to create stock item: %w", err)
+		}
+		id, _ := res.LastInsertId()
+		item.ID = int(id)
+	} else {
+		res, err := db.Exec("UPDATE stock_items SET min_stock = ?, location = ? WHERE id = ?",
+			item.MinStock, item.Location, item.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update stock item: %w", err)
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return nil, fmt.Errorf("stock item %d not found", item.ID)
+		}
+	}
+	return getStockItem(item.ID)
+}
+
+// DeleteStockItem löscht einen Lagerartikel samt Bewegungen
+func (a *App) DeleteStockItem(id int) error {
+	if _, err := db.Exec("DELETE FROM stock_items WHERE id = ?", id); err != nil {
+		return fmt.Errorf("failed to delete stock item: %w", err)
+	}
+	return nil
+}
+
+// GetStockAlerts gibt die Lagerartikel unter Mindestbestand zurück
+func (a *App) GetStockAlerts() ([]StockItem, error) {
+	items, err := a.GetStockItems()
+	if err != nil {
+		return nil, err
+	}
+	alerts := []StockItem{}
+	for _, item := range items {
+		if item.Low {
+			alerts = append(alerts, item)
+		}
+	}
+	return alerts, nil
+}
+
+// BEWEGUNGEN
+
+// BookStockMovement bucht eine Lagerbewegung in g. Wareneingang, Verbrauch und Verderb
+// erwarten eine positive Menge; bei einer Korrektur ist quantity der gezählte Bestand.
+func (a *App) BookStockMovement(stockItemID int, movementType string, quantity float64, note string) (*StockMovement, error) {
+	item, err := getStockItem(stockItemID)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := stockMovementNames[movementType]; !ok {
+		return nil, fmt.Errorf("invalid stock movement type: %s", movementType)
+	}
+	if quantity < 0 || (quantity == 0 && movementType != stockMovementCorrection) {
+		return nil, fmt.Errorf("quantity must be positive")
+	}
+
+	delta := quantity
+	switch movementType {
+	case stockMovementConsumption, stockMovementWaste:
+		delta = -quantity
+	case stockMovementCorrection:
+		delta = quantity - item.Stock
+	}
+
+	res, err := db.Exec(`
+		INSERT INTO stock_movements (stock_item_id, type, quantity, created_at, actor, note)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, stockItemID, movementType, delta, time.Now().Format(auditTimeLayout), a.currentActor(), strings.TrimSpace(note))
+	if err != nil {
+		return nil, fmt.Errorf("failed to book stock movement: %w", err)
+	}
+	id, _ := res.LastInsertId()
+
+	var movement StockMovement
+	if err := db.Get(&movement, "SELECT * FROM stock_movements WHERE id = ?", id); err != nil {
+		return nil, fmt.Errorf("failed to get stock movement: %w", err)
+	}
+	return &movement, nil
+}
+
+// GetStockMovements gibt die Bewegungen eines Lagerartikels zurück, neueste zuerst (limit 0 = alle)
+func (a *App) GetStockMovements(stockItemID int, limit int) ([]StockMovement, error) {
+	query := "SELECT * FROM stock_movements WHERE stock_item_id = ? ORDER BY created_at DESC, id DESC"
+	args := []interface{}{stockItemID}
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	movements := []StockMovement{}
+	if err := db.Select(&movements, query, args...); err != nil {
+		return nil, fmt.Errorf("failed to get stock movements: %w", err)
+	}
+	return movements, nil
+}
+
+// INVENTURBERICHT
+
+// GetInventoryReport fasst Anfangsbestand, Bewegungen und Endbestand je Lagerartikel
+// im Zeitraum from–to (YYYY-MM-DD, einschließlich) zusammen
+func (a *App) GetInventoryReport(from string, to string) (*InventoryReport, error) {
+	start, err := isoweek.ParseDate(from)
+	if err != nil {
+		return nil, err
+	}
+	end, err := isoweek.ParseDate(to)
+	if err != nil {
+		return nil, err
+	}
+	if end.Before(start) {
+		return nil, fmt.Errorf("end date must not be before start date")
+	}
+
+	items, err := a.GetStockItems()
+	if err != nil {
+		return nil, err
+	}
+
+	var movements []StockMovement
+	err = db.Select(&movements, "SELECT * FROM stock_movements WHERE created_at < ?", isoweek.FormatDate(end.AddDate(0, 0, 1)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stock movements: %w", err)
+	}
+
+	lines := map[int]*InventoryLine{}
+	report := &InventoryReport{From: isoweek.FormatDate(start), To: isoweek.FormatDate(end), Lines: []InventoryLine{}}
+	for _, item := range items {
+		lines[item.ID] = &InventoryLine{StockItemID: item.ID, ProductName: item.ProductName, MinStock: item.MinStock}
+	}
+	for _, m := range movements {
+		line, ok := lines[m.StockItemID]
+		if !ok {
+			continue
+		}
+		if m.CreatedAt.Before(start) {
+			line.Opening += m.Quantity
+			continue
+		}
+		switch m.Type {
+		case stockMovementDelivery:
+			line.Deliveries += m.Quantity
+		case stockMovementConsumption:
+			line.Consumption -= m.Quantity
+		case stockMovementWaste:
+			line.Waste -= m.Quantity
+		case stockMovementCorrection:
+			line.Corrections += m.Quantity
+		}
+	}
+	for _, item := range items {
+		line := lines[item.ID]
+		line.Closing = line.Opening + line.Deliveries - line.Consumption - line.Waste + line.Corrections
+		line.Low = line.Closing < line.MinStock
+		report.Lines = append(report.Lines, *line)
+	}
+	return report, nil
+}
+
+// ExportInventoryReportPDF exportiert den Inventurbericht mit Zählspalte (Hochformat A4)
+func (a *App) ExportInventoryReportPDF(from string, to string, outputPath string) error {
+	report, err := a.GetInventoryReport(from, to)
+	if err != nil {
+		return err
+	}
+
+	pdf := newReportPDF("P")
+	pdf.AddPage()
+
+	pageW, _ := pdf.GetPageSize()
+	marginX := 10.0
+	usableW := pageW - 2*marginX
+
+	start, _ := isoweek.ParseDate(report.From)
+	end, _ := isoweek.ParseDate(report.To)
+	pdf.SetFont("DejaVu", "B", 14)
+	pdf.CellFormat(usableW, 8, "Inventurbericht", "", 1, "L", false, 0, "")
+	pdf.SetFont("DejaVu", "", 9)
+	pdf.CellFormat(usableW, 5, fmt.Sprintf("%s – %s, Mengen in g", start.Format("02.01.2006"), end.Format("02.01.2006")), "", 1, "L", false, 0, "")
+	pdf.Ln(3)
+
+	widths := []float64{usableW - 140, 20, 20, 20, 20, 20, 20, 20}
+	headers := []string{"Artikel", "Anfang", "Eingang", "Verbrauch", "Verderb", "Korrektur", "Ende", "Gezählt"}
+	pdf.SetFont("DejaVu", "B", 8)
+	pdf.SetFillColor(220, 220, 220)
+	for i, h := range headers {
+		pdf.CellFormat(widths[i], 6, h, "1", 0, "L", true, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("DejaVu", "", 8)
+	for _, line := range report.Lines {
+		name := line.ProductName
+		if line.Low {
+			name += " (unter Mindestbestand)"
+			pdf.SetTextColor(180, 0, 0)
+		}
+		values := []float64{line.Opening, line.Deliveries, line.Consumption, line.Waste, line.Corrections, line.Closing}
+		cells := []string{name}
+		for _, v := range values {
+			cells = append(cells, fmt.Sprintf("%.0f", v))
+		}
+		cells = append(cells, "")
+		for i, text := range cells {
+			pdf.CellFormat(widths[i], 5, text, "1", 0, "L", false, 0, "")
+		}
+		pdf.SetTextColor(0, 0, 0)
+		pdf.Ln(-1)
+	}
+	if len(report.Lines) == 0 {
+		pdf.CellFormat(usableW, 6, "Keine Lagerartikel angelegt.", "", 1, "L", false, 0, "")
+	}
+
+	return pdf.OutputFileAndClose(outputPath)
+}
+
+// HILFSFUNKTIONEN
+
+// getStockItem lädt einen Lagerartikel mit aktuellem Bestand
+func getStockItem(id int) (*StockItem, error) {
+	var item StockItem
+	if err := db.Get(&item, stockItemQuery+" WHERE s.id = ?", id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("stock item %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get stock item: %w", err)
+	}
+	item.Low = item.Stock < item.MinStock
+	return &item, nil
+}
+
+// currentStock gibt den Bestand je Produkt-ID aller Lagerartikel zurück
+func currentStock() (map[int]float64, error) {
+	var rows []StockItem
+	if err := db.Select(&rows, stockItemQuery); err != nil {
+		return nil, fmt.Errorf("failed to get stock items: %w", err)
+	}
+	stock := map[int]float64{}
+	for _, item := range rows {
+		stock[item.ProductID] = item.Stock
+	}
+	return stock, nil
+}
+
+// bookPlanConsumption bucht den Verbrauch eines ausgegebenen Wochenplans für alle
+// Produkte mit Lagerartikel. Die Buchungen tragen die Wochenplan-ID, damit
+// cancelPlanConsumption sie beim Wiederöffnen gegenbuchen kann.
+func (a *App) bookPlanConsumption(tx *sqlx.Tx, plan *WeekPlan) error {
+	headcounts, err := weekHeadcounts(plan)
+	if err != nil {
+		return err
+	}
+	report := buildPortionReport(plan, headcounts)
+
+	var items []StockItem
+	if err := tx.Select(&items, stockItemQuery); err != nil {
+		return fmt.Errorf("failed to get stock items: %w", err)
+	}
+	byProduct := map[int]int{}
+	for _, item := range items {
+		byProduct[item.ProductID] = item.ID
+	}
+
+	now := time.Now().Format(auditTimeLayout)
+	note := fmt.Sprintf("KW %d/%d", plan.Week, plan.Year)
+	for _, total := range report.Totals {
+		if total.ProductID == nil || total.Quantity == nil || *total.Quantity == 0 {
+			continue
+		}
+		itemID, ok := byProduct[*total.ProductID]
+		if !ok {
+			continue
+		}
+		_, err := tx.Exec(`
+			INSERT INTO stock_movements (stock_item_id, type, quantity, created_at, actor, note, week_plan_id)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+		`, itemID, stockMovementConsumption, -*total.Quantity, now, a.currentActor(), note, plan.ID)
+		if err != nil {
+			return fmt.Errorf("failed to book consumption: %w", err)
+		}
+	}
+	return nil
+}
+
+// cancelPlanConsumption nimmt die automatischen Verbrauchsbuchungen eines Wochenplans
+// durch Gegenbuchungen zurück. Die Gegenbuchungen tragen ebenfalls die Wochenplan-ID,
+// sodass nach erneutem Ausgeben und Wiederöffnen nur der offene Saldo storniert wird.
+func (a *App) cancelPlanConsumption(tx *sqlx.Tx, plan *WeekPlan) error {
+	var open []struct {
+		StockItemID int     `db:"stock_item_id"`
+		Quantity    float64 `db:"quantity"`
+	}
+	err := tx.Select(&open, `
+		SELECT stock_item_id, SUM(quantity) AS quantity
+		FROM stock_movements
+		WHERE week_plan_id = ? AND type = ?
+		GROUP BY stock_item_id
+		HAVING SUM(quantity) != 0
+		ORDER BY stock_item_id
+	`, plan.ID, stockMovementConsumption)
+	if err != nil {
+		return fmt.Errorf("failed to get booked consumption: %w", err)
+	}
+
+	now := time.Now().Format(auditTimeLayout)
+	note := fmt.Sprintf("Storno KW %d/%d", plan.Week, plan.Year)
+	for _, o := range open {
+		_, err := tx.Exec(`
+			INSERT INTO stock_movements (stock_item_id, type, quantity, created_at, actor, note, week_plan_id)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+		`, o.StockItemID, stockMovementConsumption, -o.Quantity, now, a.currentActor(), note, plan.ID)
+		if err != nil {
+			return fmt.Errorf("failed to cancel consumption: %w", err)
+		}
+	}
+	return nil
+}
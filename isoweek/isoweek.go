@@ -0,0 +1,85 @@
+// Package isoweek rechnet zwischen Kalenderdaten und ISO-8601-Kalenderwochen um.
+//
+// Alle Daten werden als Kalendertag um Mitternacht UTC geführt, damit
+// Sommer-/Winterzeitwechsel der lokalen Zeitzone keine Tage verschieben.
+package isoweek
+
+import (
+	"fmt"
+	"time"
+)
+
+// DateLayout ist das Datumsformat der API (YYYY-MM-DD)
+const DateLayout = "2006-01-02"
+
+// Monday gibt den Montag der Kalenderwoche week im ISO-Jahr year zurück
+func Monday(year, week int) time.Time {
+	// 4. Januar liegt immer in KW 1
+	jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, time.UTC)
+	return jan4.AddDate(0, 0, 1-Weekday(jan4)+(week-1)*7)
+}
+
+// Day gibt das Datum eines Wochentags (1=Mo … 7=So) der Kalenderwoche zurück
+func Day(year, week, day int) time.Time {
+	return Monday(year, week).AddDate(0, 0, day-1)
+}
+
+// Of gibt ISO-Jahr, Kalenderwoche und Wochentag (1=Mo … 7=So) des Kalendertags von t zurück.
+// Maßgeblich ist das Datum in der Zeitzone von t.
+func Of(t time.Time) (year, week, day int) {
+	date := Date(t)
+	year, week = date.ISOWeek()
+	return year, week, Weekday(date)
+}
+
+// Date schneidet die Uhrzeit ab und gibt den Kalendertag von t als Mitternacht UTC zurück
+func Date(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// Weekday gibt den Wochentag von t mit 1=Mo … 7=So zurück
+func Weekday(t time.Time) int {
+	day := int(t.Weekday())
+	if day == 0 {
+		day = 7
+	}
+	return day
+}
+
+// WeeksInYear gibt die Anzahl Kalenderwochen eines ISO-Jahres zurück (52 oder 53)
+func WeeksInYear(year int) int {
+	// 28. Dezember liegt immer in der letzten KW
+	_, week := time.Date(year, time.December, 28, 0, 0, 0, 0, time.UTC).ISOWeek()
+	return week
+}
+
+// Valid prüft, ob es die Kalenderwoche im ISO-Jahr gibt
+func Valid(year, week int) bool {
+	return week >= 1 && week <= WeeksInYear(year)
+}
+
+// AddWeeks verschiebt eine Kalenderwoche um n Wochen (auch über Jahresgrenzen)
+func AddWeeks(year, week, n int) (int, int) {
+	return Monday(year, week).AddDate(0, 0, 7*n).ISOWeek()
+}
+
+// WeeksBetween gibt die Anzahl Wochen von einer Kalenderwoche bis zu einer anderen zurück
+func WeeksBetween(fromYear, fromWeek, toYear, toWeek int) int {
+	days := Monday(toYear, toWeek).Sub(Monday(fromYear, fromWeek)).Hours() / 24
+	return int(days) / 7
+}
+
+// ParseDate liest ein Datum im Format YYYY-MM-DD
+func ParseDate(s string) (time.Time, error) {
+	t, err := time.Parse(DateLayout, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, expected YYYY-MM-DD: %w", s, err)
+	}
+	return t, nil
+}
+
+// FormatDate formatiert einen Kalendertag im Format YYYY-MM-DD
+func FormatDate(t time.Time) string {
+	return t.Format(DateLayout)
+}
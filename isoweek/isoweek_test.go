@@ -0,0 +1,130 @@
+package isoweek
+
+import (
+	"testing"
+	"time"
+)
+
+func date(s string) time.Time {
+	t, err := ParseDate(s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestOf(t *testing.T) {
+	tests := []struct {
+		date            string
+		year, week, day int
+	}{
+		{"2026-01-01", 2026, 1, 4},
+		{"2025-12-29", 2026, 1, 1},  // Montag gehört schon zu KW 1 des Folgejahres
+		{"2021-01-01", 2020, 53, 5}, // 1. Januar in KW 53 des Vorjahres
+		{"2021-01-03", 2020, 53, 7},
+		{"2021-01-04", 2021, 1, 1},
+		{"2022-01-01", 2021, 52, 6}, // 1. Januar in KW 52 des Vorjahres
+		{"2023-01-01", 2022, 52, 7},
+		{"2026-12-31", 2026, 53, 4},
+		{"2027-01-01", 2026, 53, 5},
+		{"2024-12-30", 2025, 1, 1},
+	}
+	for _, tt := range tests {
+		year, week, day := Of(date(tt.date))
+		if year != tt.year || week != tt.week || day != tt.day {
+			t.Errorf("Of(%s) = %d-W%02d-%d, want %d-W%02d-%d", tt.date, year, week, day, tt.year, tt.week, tt.day)
+		}
+	}
+}
+
+func TestMonday(t *testing.T) {
+	tests := []struct {
+		year, week int
+		want       string
+	}{
+		{2026, 1, "2025-12-29"},
+		{2020, 53, "2020-12-28"},
+		{2021, 1, "2021-01-04"},
+		{2021, 52, "2021-12-27"},
+		{2026, 53, "2026-12-28"},
+		{2015, 1, "2014-12-29"},
+	}
+	for _, tt := range tests {
+		if got := FormatDate(Monday(tt.year, tt.week)); got != tt.want {
+			t.Errorf("Monday(%d, %d) = %s, want %s", tt.year, tt.week, got, tt.want)
+		}
+	}
+}
+
+func TestMondayRoundTrip(t *testing.T) {
+	for year := 2000; year <= 2040; year++ {
+		for week := 1; week <= WeeksInYear(year); week++ {
+			for day := 1; day <= 7; day++ {
+				y, w, d := Of(Day(year, week, day))
+				if y != year || w != week || d != day {
+					t.Fatalf("Of(Day(%d, %d, %d)) = %d-W%02d-%d", year, week, day, y, w, d)
+				}
+			}
+		}
+	}
+}
+
+func TestWeeksInYear(t *testing.T) {
+	tests := map[int]int{2015: 53, 2020: 53, 2021: 52, 2022: 52, 2025: 52, 2026: 53, 2032: 53}
+	for year, want := range tests {
+		if got := WeeksInYear(year); got != want {
+			t.Errorf("WeeksInYear(%d) = %d, want %d", year, got, want)
+		}
+	}
+	if Valid(2021, 53) {
+		t.Error("Valid(2021, 53) = true, want false")
+	}
+	if !Valid(2020, 53) {
+		t.Error("Valid(2020, 53) = false, want true")
+	}
+}
+
+func TestAddWeeks(t *testing.T) {
+	if y, w := AddWeeks(2020, 52, 1); y != 2020 || w != 53 {
+		t.Errorf("AddWeeks(2020, 52, 1) = %d/%d, want 2020/53", y, w)
+	}
+	if y, w := AddWeeks(2020, 53, 1); y != 2021 || w != 1 {
+		t.Errorf("AddWeeks(2020, 53, 1) = %d/%d, want 2021/1", y, w)
+	}
+	if y, w := AddWeeks(2022, 1, -1); y != 2021 || w != 52 {
+		t.Errorf("AddWeeks(2022, 1, -1) = %d/%d, want 2021/52", y, w)
+	}
+	if n := WeeksBetween(2020, 50, 2021, 2); n != 5 {
+		t.Errorf("WeeksBetween(2020/50, 2021/2) = %d, want 5", n)
+	}
+	if n := WeeksBetween(2021, 2, 2020, 50); n != -5 {
+		t.Errorf("WeeksBetween(2021/2, 2020/50) = %d, want -5", n)
+	}
+}
+
+func TestDSTIndependent(t *testing.T) {
+	// Kurz vor Mitternacht in einer Zone mit großem Versatz bleibt der lokale Kalendertag maßgeblich
+	zone := time.FixedZone("UTC+14", 14*3600)
+	local := time.Date(2026, time.March, 29, 23, 30, 0, 0, zone)
+	if year, week, day := Of(local); year != 2026 || week != 13 || day != 7 {
+		t.Errorf("Of(%s) = %d-W%02d-%d, want 2026-W13-7", local, year, week, day)
+	}
+
+	// Über die Zeitumstellung hinweg liegen Montage immer genau 7 Tage auseinander
+	before, after := Monday(2026, 13), Monday(2026, 14)
+	if diff := after.Sub(before); diff != 7*24*time.Hour {
+		t.Errorf("Monday(2026, 14) - Monday(2026, 13) = %s, want 168h", diff)
+	}
+	if before.Location() != time.UTC {
+		t.Errorf("Monday returned location %s, want UTC", before.Location())
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	if _, err := ParseDate("2026-13-01"); err == nil {
+		t.Error("ParseDate accepted an invalid month")
+	}
+	if _, err := ParseDate("01.02.2026"); err == nil {
+		t.Error("ParseDate accepted a German date format")
+	}
+}
@@ -19,43 +19,604 @@ type Additive struct {
 
 // Product repräsentiert ein Produkt mit Allergenen und Zusatzstoffen
 type Product struct {
+	ID                  int         `json:"id" db:"id"`
+	Name                string      `json:"name" db:"name"`
+	Multiline           bool        `json:"multiline" db:"multiline"`
+	PortionSize         *float64    `json:"portion_size" db:"portion_size"` // Portionsgröße in g
+	Vegetarian          bool        `json:"vegetarian" db:"vegetarian"`
+	Vegan               bool        `json:"vegan" db:"vegan"`
+	PorkFree            bool        `json:"pork_free" db:"pork_free"` // schweinefleischfrei
+	Archived            bool        `json:"archived" db:"archived"`   // ausgeblendet, aber in alten Plänen sichtbar
+	Revision            int         `json:"revision" db:"revision"`   // aktuelle Revisionsnummer
+	Allergens           []Allergen  `json:"allergens"`
+	Additives           []Additive  `json:"additives"`
+	FoodGroups          []FoodGroup `json:"food_groups"`
+	Categories          []Category  `json:"categories"`
+	Tags                []Tag       `json:"tags"`
+	Nutrition           *Nutrition  `json:"nutrition,omitempty"`             // je 100 g
+	NutritionPerPortion *Nutrition  `json:"nutrition_per_portion,omitempty"` // aus Portionsgröße berechnet
+}
+
+// Category repräsentiert eine (ggf. verschachtelte) Produktkategorie
+type Category struct {
 	ID        int        `json:"id" db:"id"`
 	Name      string     `json:"name" db:"name"`
-	Multiline bool       `json:"multiline" db:"multiline"`
-	Allergens []Allergen `json:"allergens"`
-	Additives []Additive `json:"additives"`
+	ParentID  *int       `json:"parent_id" db:"parent_id"`
+	Color     *string    `json:"color" db:"color"` // z.B. "#7CB342", für den PDF-Export
+	SortOrder int        `json:"sort_order" db:"sort_order"`
+	Children  []Category `json:"children,omitempty"`
+}
+
+// Tag repräsentiert ein freies Schlagwort
+type Tag struct {
+	ID   int    `json:"id" db:"id"`
+	Name string `json:"name" db:"name"`
+}
+
+// ProductFilter schränkt die Produktliste ein (leere Felder = kein Filter)
+type ProductFilter struct {
+	CategoryID       *int     `json:"category_id"`       // inkl. aller Unterkategorien
+	TagIDs           []int    `json:"tag_ids"`           // Produkt muss alle Schlagworte tragen
+	ExcludeAllergens []string `json:"exclude_allergens"` // nur Produkte ohne diese Allergene
+	Vegetarian       bool     `json:"vegetarian"`
+	Vegan            bool     `json:"vegan"`
+	PorkFree         bool     `json:"pork_free"`
+	IncludeArchived  bool     `json:"include_archived"` // archivierte Produkte mit ausgeben
+}
+
+// ProductUsage repräsentiert die Verwendung eines Produkts in einem Wochenplan
+type ProductUsage struct {
+	WeekPlanID int `json:"week_plan_id" db:"week_plan_id"`
+	Year       int `json:"year" db:"year"`
+	Week       int `json:"week" db:"week"`
+	Entries    int `json:"entries" db:"entries"`
+}
+
+// TemplateUsage beschreibt eine Vorlagen- oder Rotationswoche, in der ein Produkt vorkommt
+type TemplateUsage struct {
+	TemplateWeekID int     `json:"template_week_id" db:"template_week_id"`
+	Name           string  `json:"name" db:"name"`
+	RotationName   *string `json:"rotation_name" db:"rotation_name"` // nil = eigenständige Vorlage
+	Entries        int     `json:"entries" db:"entries"`
+}
+
+// ProductUsageReport fasst zusammen, wo ein Produkt verwendet wird
+type ProductUsageReport struct {
+	Plans     []ProductUsage  `json:"plans"`
+	Templates []TemplateUsage `json:"templates"`
+}
+
+// MergeResult repräsentiert das Ergebnis einer Produktzusammenführung
+type MergeResult struct {
+	Target            *Product `json:"target"`
+	EntriesMoved      int      `json:"entries_moved"`
+	DuplicatesRemoved int      `json:"duplicates_removed"`
+}
+
+// FoodGroup repräsentiert eine Lebensmittelgruppe (z.B. Gemüse, Vollkorn)
+type FoodGroup struct {
+	ID   string `json:"id" db:"id"`
+	Name string `json:"name" db:"name"`
+}
+
+// Nutrition repräsentiert Nährwertangaben (je 100 g oder je Portion)
+type Nutrition struct {
+	EnergyKJ      *float64 `json:"energy_kj" db:"energy_kj"`
+	EnergyKcal    *float64 `json:"energy_kcal" db:"energy_kcal"`
+	Protein       *float64 `json:"protein" db:"protein"`             // g
+	Fat           *float64 `json:"fat" db:"fat"`                     // g
+	SaturatedFat  *float64 `json:"saturated_fat" db:"saturated_fat"` // g
+	Carbohydrates *float64 `json:"carbohydrates" db:"carbohydrates"` // g
+	Sugar         *float64 `json:"sugar" db:"sugar"`                 // g
+	Fibre         *float64 `json:"fibre" db:"fibre"`                 // g
+	Salt          *float64 `json:"salt" db:"salt"`                   // g
+}
+
+// DayNutrition repräsentiert die Nährwertsumme eines Tages
+type DayNutrition struct {
+	Day     int       `json:"day"`
+	Totals  Nutrition `json:"totals"`
+	Counted int       `json:"counted"` // Einträge mit Nährwerten und Portionsgröße
+	Missing int       `json:"missing"` // Einträge ohne verwertbare Angaben
+}
+
+// NutritionSummary repräsentiert die Nährwertübersicht eines Wochenplans
+type NutritionSummary struct {
+	WeekPlanID int            `json:"week_plan_id"`
+	Year       int            `json:"year"`
+	Week       int            `json:"week"`
+	Days       []DayNutrition `json:"days"`
+	Totals     Nutrition      `json:"totals"`
+	Missing    int            `json:"missing"`
+}
+
+// ProductRevision repräsentiert den Stand eines Produkts zu einem Zeitpunkt
+// (Name und Kennzeichnung, wie sie auf dem Speiseplan erscheinen)
+type ProductRevision struct {
+	ID          int       `json:"id"`
+	ProductID   int       `json:"product_id"`
+	Revision    int       `json:"revision"`
+	Name        string    `json:"name"`
+	Multiline   bool      `json:"multiline"`
+	AllergenIDs []string  `json:"allergen_ids"`
+	AdditiveIDs []string  `json:"additive_ids"`
+	CreatedAt   time.Time `json:"created_at"`
 }
 
 // WeekPlan repräsentiert einen Wochenplan
 type WeekPlan struct {
-	ID        int         `json:"id" db:"id"`
-	Year      int         `json:"year" db:"year"`
-	Week      int         `json:"week" db:"week"`
-	CreatedAt time.Time   `json:"created_at" db:"created_at"`
-	Entries   []PlanEntry `json:"entries"`
+	ID          int          `json:"id" db:"id"`
+	Year        int          `json:"year" db:"year"`
+	Week        int          `json:"week" db:"week"`
+	CreatedAt   time.Time    `json:"created_at" db:"created_at"`
+	Status      string       `json:"status" db:"status"` // 'draft', 'review', 'approved', 'published', 'served'
+	ApprovedBy  *string      `json:"approved_by" db:"approved_by"`
+	ApprovedAt  *time.Time   `json:"approved_at" db:"approved_at"`
+	Archived    bool         `json:"archived" db:"archived"` // archiviert = schreibgeschützt
+	Entries     []PlanEntry  `json:"entries"`
 	SpecialDays []SpecialDay `json:"special_days"`
+	Days        []PlanDay    `json:"days"` // Kalenderdaten Mo–So
+}
+
+// WeekPlanDeletion beschreibt, was beim Löschen eines Wochenplans entfernt wird.
+// Das Token bestätigt genau diesen Stand und wird beim Löschen zurückgegeben.
+type WeekPlanDeletion struct {
+	WeekPlanID  int    `json:"week_plan_id"`
+	Year        int    `json:"year"`
+	Week        int    `json:"week"`
+	Entries     int    `json:"entries"`
+	SpecialDays int    `json:"special_days"`
+	Token       string `json:"token"`
+}
+
+// WeekPlanBackup ist die Sicherung eines gelöschten Wochenplans
+type WeekPlanBackup struct {
+	ID         int       `json:"id" db:"id"`
+	WeekPlanID int       `json:"week_plan_id" db:"week_plan_id"`
+	Year       int       `json:"year" db:"year"`
+	Week       int       `json:"week" db:"week"`
+	DeletedAt  time.Time `json:"deleted_at" db:"deleted_at"`
+	DeletedBy  string    `json:"deleted_by" db:"deleted_by"`
+	Data       string    `json:"-" db:"data"` // WeekPlan als JSON
+}
+
+// PlanDay ist ein Kalendertag eines Wochenplans
+type PlanDay struct {
+	Day       int    `json:"day"`       // 1=Mo … 7=So
+	Date      string `json:"date"`      // YYYY-MM-DD
+	Name      string `json:"name"`      // z.B. "Montag"
+	Operating bool   `json:"operating"` // Betriebstag der Einrichtung
 }
 
 // PlanEntry repräsentiert einen Eintrag im Wochenplan
 type PlanEntry struct {
-	ID         int      `json:"id" db:"id"`
-	WeekPlanID int      `json:"week_plan_id" db:"week_plan_id"`
-	Day        int      `json:"day" db:"day"` // 1=Mo, 2=Di, 3=Mi, 4=Do, 5=Fr
-	Meal       string   `json:"meal" db:"meal"` // 'fruehstueck' oder 'vesper'
-	Slot       int      `json:"slot" db:"slot"` // Reihenfolge innerhalb des Tages
-	ProductID  *int     `json:"product_id" db:"product_id"`
-	Product    *Product `json:"product,omitempty"`
-	CustomText *string  `json:"custom_text" db:"custom_text"`
-	GroupLabel *string  `json:"group_label" db:"group_label"` // 'Krippe', 'Kita', 'Hort', etc.
+	ID                int      `json:"id" db:"id"`
+	WeekPlanID        int      `json:"week_plan_id" db:"week_plan_id"`
+	Day               int      `json:"day" db:"day"`   // 1=Mo … 7=So, nur Betriebstage
+	Meal              string   `json:"meal" db:"meal"` // 'fruehstueck' oder 'vesper'
+	Slot              int      `json:"slot" db:"slot"` // Reihenfolge innerhalb des Tages
+	ProductID         *int     `json:"product_id" db:"product_id"`
+	Product           *Product `json:"product,omitempty"`
+	ProductRevisionID *int     `json:"product_revision_id" db:"product_revision_id"` // Produktstand zum Planungszeitpunkt
+	ProductRevision   *int     `json:"product_revision,omitempty"`                   // Revisionsnummer
+	RevisionChanged   bool     `json:"revision_changed"`                             // Produkt seit der Planung geändert
+	CustomText        *string  `json:"custom_text" db:"custom_text"`
+	GroupLabel        *string  `json:"group_label" db:"group_label"` // 'Krippe', 'Kita', 'Hort', etc.
 }
 
 // SpecialDay repräsentiert einen Sondertag (Feiertag, Schließtag, etc.)
 type SpecialDay struct {
-	ID         int    `json:"id" db:"id"`
-	WeekPlanID int    `json:"week_plan_id" db:"week_plan_id"`
-	Day        int    `json:"day" db:"day"` // 1=Mo, 2=Di, ...
-	Type       string `json:"type" db:"type"` // 'feiertag' oder 'schliesstag'
-	Label      *string `json:"label" db:"label"` // z.B. "Neujahr", "Teamtag"
+	ID         int       `json:"id" db:"id"`
+	WeekPlanID int       `json:"week_plan_id" db:"week_plan_id"`
+	Day        int       `json:"day" db:"day"`       // 1=Mo, 2=Di, ...
+	Type       string    `json:"type" db:"type"`     // 'feiertag', 'schliesstag', 'ausflug', 'teamtag', 'brueckentag'
+	Label      *string   `json:"label" db:"label"`   // z.B. "Neujahr", "Lunchpaket"
+	Meals      scopeList `json:"meals" db:"meals"`   // betroffene Mahlzeiten, leer = ganzer Tag
+	Groups     scopeList `json:"groups" db:"groups"` // betroffene Gruppen, leer = alle Gruppen
+}
+
+// DGERule repräsentiert eine Häufigkeitsregel aus dem DGE-Qualitätsstandard.
+// MinCount/MaxCount beziehen sich auf 5 Verpflegungstage und werden auf den
+// geprüften Zeitraum hochgerechnet.
+type DGERule struct {
+	ID          int      `json:"id"`
+	Name        string   `json:"name"`
+	FoodGroups  []string `json:"food_groups"`
+	Meal        *string  `json:"meal"` // nil = alle Mahlzeiten
+	MinCount    *int     `json:"min_count"`
+	MaxCount    *int     `json:"max_count"`
+	Explanation string   `json:"explanation"`
+	Enabled     bool     `json:"enabled"`
+}
+
+// DGEFinding repräsentiert das Ergebnis einer Regel für den geprüften Zeitraum
+type DGEFinding struct {
+	RuleID      int    `json:"rule_id"`
+	RuleName    string `json:"rule_name"`
+	Passed      bool   `json:"passed"`
+	Count       int    `json:"count"` // Tage, an denen die Lebensmittelgruppe angeboten wurde
+	Min         *int   `json:"min"`
+	Max         *int   `json:"max"`
+	Message     string `json:"message"`
+	Explanation string `json:"explanation"`
+}
+
+// DGEReport repräsentiert die DGE-Prüfung eines oder mehrerer Wochenpläne
+type DGEReport struct {
+	Year          int          `json:"year"`
+	Week          int          `json:"week"`
+	Weeks         int          `json:"weeks"`
+	OperatingDays int          `json:"operating_days"`
+	MissingWeeks  []string     `json:"missing_weeks"` // z.B. "KW 3/2026" ohne Plan
+	Passed        bool         `json:"passed"`
+	Findings      []DGEFinding `json:"findings"`
+}
+
+// Headcount ist die erwartete Kinderzahl einer Gruppe an einem Wochentag
+type Headcount struct {
+	GroupLabel string `json:"group_label" db:"group_label"`
+	Day        int    `json:"day" db:"day"` // 1=Mo … 7=So
+	Count      int    `json:"count" db:"count"`
+}
+
+// WeekHeadcount ist die erwartete Kinderzahl einer Gruppe an einem Tag eines Wochenplans
+type WeekHeadcount struct {
+	GroupLabel string         `json:"group_label"`
+	Day        int            `json:"day"`
+	Count      int            `json:"count"`    // aus dem Wochenmuster oder der Abweichung dieser Woche
+	Override   bool           `json:"override"` // für diese Woche abweichend eingetragen
+	Meals      map[string]int `json:"meals"`    // je Mahlzeit nach Abzug der Sondertage
+}
+
+// PortionLine ist der Bedarf eines Planeintrags an einem Tag zu einer Mahlzeit
+type PortionLine struct {
+	Day         int      `json:"day"`
+	Meal        string   `json:"meal"`
+	ProductID   *int     `json:"product_id"` // nil bei Freitext
+	Name        string   `json:"name"`
+	GroupLabel  *string  `json:"group_label"` // nil = alle Gruppen
+	Portions    int      `json:"portions"`
+	PortionSize *float64 `json:"portion_size"` // in g
+	Quantity    *float64 `json:"quantity"`     // in g, nil ohne Portionsgröße
+}
+
+// PortionTotal ist der Wochenbedarf eines Produkts
+type PortionTotal struct {
+	ProductID   *int     `json:"product_id"`
+	Name        string   `json:"name"`
+	Portions    int      `json:"portions"`
+	PortionSize *float64 `json:"portion_size"`
+	Quantity    *float64 `json:"quantity"`
+}
+
+// PortionReport fasst Kinderzahlen und Portionsbedarf eines Wochenplans zusammen
+type PortionReport struct {
+	WeekPlanID         int             `json:"week_plan_id"`
+	Year               int             `json:"year"`
+	Week               int             `json:"week"`
+	Headcounts         []WeekHeadcount `json:"headcounts"`
+	Lines              []PortionLine   `json:"lines"`
+	Totals             []PortionTotal  `json:"totals"`
+	MissingPortionSize []string        `json:"missing_portion_size"` // Produkte ohne Portionsgröße
+}
+
+// Supplier repräsentiert einen Lieferanten
+type Supplier struct {
+	ID             int    `json:"id"`
+	Name           string `json:"name"`
+	Contact        string `json:"contact"` // Ansprechpartner
+	Email          string `json:"email"`
+	Phone          string `json:"phone"`
+	CustomerNumber string `json:"customer_number"` // unsere Kundennummer beim Lieferanten
+	DeliveryDays   []int  `json:"delivery_days"`   // 1=Mo … 7=So, leer = nach Bedarf
+	LeadTimeDays   int    `json:"lead_time_days"`  // Bestellvorlauf in Tagen
+}
+
+// ProductSupplier ordnet ein Produkt seinem Lieferanten zu
+type ProductSupplier struct {
+	ProductID     int      `json:"product_id" db:"product_id"`
+	ProductName   string   `json:"product_name" db:"product_name"`
+	SupplierID    int      `json:"supplier_id" db:"supplier_id"`
+	SupplierName  string   `json:"supplier_name" db:"supplier_name"`
+	ArticleNumber string   `json:"article_number" db:"article_number"`
+	PackSize      *float64 `json:"pack_size" db:"pack_size"` // Gebindegröße in g
+}
+
+// OrderLine ist eine Position einer Bestellung
+type OrderLine struct {
+	ProductID     int      `json:"product_id"`
+	Name          string   `json:"name"`
+	ArticleNumber string   `json:"article_number"`
+	Portions      int      `json:"portions"`
+	Required      *float64 `json:"required"`  // Bedarf in g, nil ohne Portionsgröße
+	PackSize      *float64 `json:"pack_size"` // Gebindegröße in g
+	Packs         *int     `json:"packs"`     // aufgerundete Anzahl Gebinde
+}
+
+// SupplierOrder ist eine Bestellung bei einem Lieferanten für einen Liefertermin
+type SupplierOrder struct {
+	Supplier     Supplier    `json:"supplier"`
+	DeliveryDate string      `json:"delivery_date"` // YYYY-MM-DD
+	OrderBy      string      `json:"order_by"`      // spätester Bestelltag, YYYY-MM-DD
+	Overdue      bool        `json:"overdue"`       // Bestellfrist bereits verstrichen
+	Lines        []OrderLine `json:"lines"`
+}
+
+// OrderProposal fasst die Bestellungen für den Bedarf eines Wochenplans zusammen
+type OrderProposal struct {
+	WeekPlanID int             `json:"week_plan_id"`
+	Year       int             `json:"year"`
+	Week       int             `json:"week"`
+	Orders     []SupplierOrder `json:"orders"`
+	Unassigned []string        `json:"unassigned"` // Produkte und Freitexte ohne Lieferant
+}
+
+// ShoppingListOptions wählt Zeitraum und Vorrat für die Einkaufsliste
+type ShoppingListOptions struct {
+	From  string          `json:"from"`  // YYYY-MM-DD, einschließlich
+	To    string          `json:"to"`    // YYYY-MM-DD, einschließlich
+	Stock map[int]float64 `json:"stock"` // Vorrat je Produkt-ID in g, nil = Lagerbestand
+}
+
+// ShoppingItem ist der zusammengefasste Bedarf eines Produkts im Zeitraum
+type ShoppingItem struct {
+	ProductID   *int     `json:"product_id"` // nil bei Freitext
+	Name        string   `json:"name"`
+	Supplier    string   `json:"supplier"`
+	Category    string   `json:"category"` // oberste Kategorie
+	Portions    int      `json:"portions"`
+	PortionSize *float64 `json:"portion_size"` // in g
+	Required    *float64 `json:"required"`     // in g, nil ohne Portionsgröße
+	Stock       float64  `json:"stock"`        // Vorrat in g
+	ToBuy       *float64 `json:"to_buy"`       // Bedarf abzüglich Vorrat in g
+}
+
+// ShoppingGroup fasst die Artikel eines Lieferanten in einer Kategorie zusammen
+type ShoppingGroup struct {
+	Supplier string         `json:"supplier"`
+	Category string         `json:"category"`
+	Items    []ShoppingItem `json:"items"`
+}
+
+// ShoppingList ist die Einkaufsliste für einen Zeitraum
+type ShoppingList struct {
+	From               string          `json:"from"`
+	To                 string          `json:"to"`
+	Weeks              []WeekRef       `json:"weeks"` // berücksichtigte Wochenpläne
+	Groups             []ShoppingGroup `json:"groups"`
+	MissingPortionSize []string        `json:"missing_portion_size"`
+}
+
+// ProductPrice ist der Preis eines Produkts ab einem Stichtag
+type ProductPrice struct {
+	ID         int     `json:"id" db:"id"`
+	ProductID  int     `json:"product_id" db:"product_id"`
+	PricePerKg float64 `json:"price_per_kg" db:"price_per_kg"` // Euro je kg
+	ValidFrom  string  `json:"valid_from" db:"valid_from"`     // YYYY-MM-DD
+	ValidTo    *string `json:"valid_to" db:"-"`                // letzter Gültigkeitstag, nil = unbefristet
+}
+
+// DailyBudget ist das Verpflegungsbudget je Kind und Tag ab einem Stichtag
+type DailyBudget struct {
+	ID        int     `json:"id" db:"id"`
+	Amount    float64 `json:"amount" db:"amount"`         // Euro je Kind und Tag
+	ValidFrom string  `json:"valid_from" db:"valid_from"` // YYYY-MM-DD
+	ValidTo   *string `json:"valid_to" db:"-"`            // letzter Gültigkeitstag, nil = unbefristet
+}
+
+// CostLine sind die Kosten eines Planeintrags
+type CostLine struct {
+	Day            int      `json:"day"`
+	Meal           string   `json:"meal"`
+	ProductID      *int     `json:"product_id"`
+	Name           string   `json:"name"`
+	Portions       int      `json:"portions"`
+	CostPerPortion *float64 `json:"cost_per_portion"` // nil ohne Preis oder Portionsgröße
+	Cost           *float64 `json:"cost"`
+}
+
+// DayCost vergleicht die Kosten eines Verpflegungstags mit dem Budget
+type DayCost struct {
+	Date           string   `json:"date"` // YYYY-MM-DD
+	Day            int      `json:"day"`
+	Children       int      `json:"children"` // Kinder mit mindestens einer Mahlzeit
+	Cost           float64  `json:"cost"`
+	BudgetPerChild float64  `json:"budget_per_child"` // an diesem Tag gültiges Budget je Kind
+	Budget         float64  `json:"budget"`
+	CostPerChild   *float64 `json:"cost_per_child"` // nil ohne Kinder
+}
+
+// WeekCostReport fasst die Kosten eines Wochenplans zusammen
+type WeekCostReport struct {
+	WeekPlanID    int           `json:"week_plan_id"`
+	Year          int           `json:"year"`
+	Week          int           `json:"week"`
+	Budgets       []DailyBudget `json:"budgets"` // in der Woche gültige Budgets
+	Days          []DayCost     `json:"days"`
+	Lines         []CostLine    `json:"lines"`
+	Cost          float64       `json:"cost"`
+	Budget        float64       `json:"budget"`
+	MissingPrices []string      `json:"missing_prices"` // ohne Preis oder Portionsgröße
+}
+
+// MonthCostReport vergleicht die geplanten Kosten eines Monats mit dem Budget
+type MonthCostReport struct {
+	Year          int           `json:"year"`
+	Month         int           `json:"month"`   // 1-12
+	Budgets       []DailyBudget `json:"budgets"` // im Monat gültige Budgets
+	Days          []DayCost     `json:"days"`
+	ChildDays     int           `json:"child_days"` // Summe der Kinder über alle Tage
+	Cost          float64       `json:"cost"`
+	Budget        float64       `json:"budget"`
+	Difference    float64       `json:"difference"` // Budget minus Kosten
+	MissingPrices []string      `json:"missing_prices"`
+}
+
+// StockItem ist ein Lagerartikel mit aktuellem Bestand (Mengen in g)
+type StockItem struct {
+	ID          int     `json:"id" db:"id"`
+	ProductID   int     `json:"product_id" db:"product_id"`
+	ProductName string  `json:"product_name" db:"product_name"`
+	MinStock    float64 `json:"min_stock" db:"min_stock"`
+	Location    string  `json:"location" db:"location"`
+	Stock       float64 `json:"stock" db:"stock"`
+	Low         bool    `json:"low" db:"-"` // unter Mindestbestand
+}
+
+// StockMovement ist eine Lagerbewegung; Quantity ist die Bestandsänderung in g (Abgänge negativ)
+type StockMovement struct {
+	ID          int       `json:"id" db:"id"`
+	StockItemID int       `json:"stock_item_id" db:"stock_item_id"`
+	Type        string    `json:"type" db:"type"` // 'delivery', 'consumption', 'waste', 'correction'
+	Quantity    float64   `json:"quantity" db:"quantity"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	Actor       string    `json:"actor" db:"actor"`
+	Note        string    `json:"note" db:"note"`
+	WeekPlanID  *int      `json:"week_plan_id" db:"week_plan_id"` // automatische Verbrauchsbuchung
+}
+
+// InventoryLine fasst die Bewegungen eines Lagerartikels im Berichtszeitraum zusammen (in g)
+type InventoryLine struct {
+	StockItemID int     `json:"stock_item_id"`
+	ProductName string  `json:"product_name"`
+	Opening     float64 `json:"opening"`
+	Deliveries  float64 `json:"deliveries"`
+	Consumption float64 `json:"consumption"`
+	Waste       float64 `json:"waste"`
+	Corrections float64 `json:"corrections"` // Saldo der Inventurkorrekturen
+	Closing     float64 `json:"closing"`
+	MinStock    float64 `json:"min_stock"`
+	Low         bool    `json:"low"`
+}
+
+// InventoryReport ist der Inventurbericht für einen Zeitraum
+type InventoryReport struct {
+	From  string          `json:"from"`
+	To    string          `json:"to"`
+	Lines []InventoryLine `json:"lines"`
+}
+
+// PlanSelection wählt einen Ausschnitt eines Wochenplans (Tage und Mahlzeit)
+type PlanSelection struct {
+	Year int    `json:"year"`
+	Week int    `json:"week"`
+	Day  int    `json:"day"`  // 1=Mo … 7=So, 0 = ganze Woche
+	Days int    `json:"days"` // Anzahl Tage ab Day (0 oder 1 = nur Day)
+	Meal string `json:"meal"` // 'fruehstueck', 'vesper' oder leer = alle
+}
+
+// CopyOptions steuert das Kopieren von Planausschnitten
+type CopyOptions struct {
+	Mode               string `json:"mode"`                 // 'append', 'replace' oder 'skip' (nur in leere Mahlzeiten)
+	IncludeSpecialDays bool   `json:"include_special_days"` // Sondertage der Quelltage mitkopieren
+}
+
+// CopyResult fasst das Ergebnis eines Kopiervorgangs in einen Wochenplan zusammen
+type CopyResult struct {
+	Plan               *WeekPlan   `json:"plan"`
+	Created            bool        `json:"created"` // Zielplan wurde neu angelegt
+	Copied             []PlanEntry `json:"copied"`  // neu angelegte Einträge im Zielplan
+	Skipped            []PlanEntry `json:"skipped"` // Quelleinträge, die im Ziel schon vorhanden waren
+	Blocked            []PlanEntry `json:"blocked"` // Quelleinträge an Tagen ohne Verpflegung oder Sondertagen im Ziel
+	Removed            int         `json:"removed"` // beim Überschreiben entfernte Einträge
+	SpecialDaysCopied  int         `json:"special_days_copied"`
+	SpecialDaysSkipped int         `json:"special_days_skipped"`
+}
+
+// Rotation repräsentiert einen Speiseplan-Zyklus aus mehreren Vorlagenwochen.
+// Die Ankerwoche ist die Kalenderwoche, in der die erste Rotationswoche gilt.
+type Rotation struct {
+	ID         int            `json:"id" db:"id"`
+	Name       string         `json:"name" db:"name"`
+	AnchorYear int            `json:"anchor_year" db:"anchor_year"`
+	AnchorWeek int            `json:"anchor_week" db:"anchor_week"`
+	CreatedAt  time.Time      `json:"created_at" db:"created_at"`
+	Weeks      []TemplateWeek `json:"weeks"`
+}
+
+// TemplateWeek repräsentiert eine Vorlagenwoche (Teil einer Rotation oder eigenständige Vorlage)
+type TemplateWeek struct {
+	ID         int             `json:"id" db:"id"`
+	Name       string          `json:"name" db:"name"`
+	RotationID *int            `json:"rotation_id" db:"rotation_id"`
+	Position   int             `json:"position" db:"position"` // 0-basiert innerhalb der Rotation
+	CreatedAt  time.Time       `json:"created_at" db:"created_at"`
+	Entries    []TemplateEntry `json:"entries"`
+}
+
+// TemplateEntry repräsentiert einen Eintrag einer Vorlagenwoche
+type TemplateEntry struct {
+	ID             int      `json:"id" db:"id"`
+	TemplateWeekID int      `json:"template_week_id" db:"template_week_id"`
+	Day            int      `json:"day" db:"day"`
+	Meal           string   `json:"meal" db:"meal"`
+	Slot           int      `json:"slot" db:"slot"`
+	ProductID      *int     `json:"product_id" db:"product_id"`
+	Product        *Product `json:"product,omitempty"`
+	CustomText     *string  `json:"custom_text" db:"custom_text"`
+	GroupLabel     *string  `json:"group_label" db:"group_label"`
+}
+
+// WeekRef bezeichnet eine Kalenderwoche
+type WeekRef struct {
+	Year int `json:"year"`
+	Week int `json:"week"`
+}
+
+// RotationResult fasst das Ergebnis einer Rotationsanwendung zusammen
+type RotationResult struct {
+	Generated []WeekRef `json:"generated"` // neu erzeugte oder neu befüllte Wochen
+	Skipped   []WeekRef `json:"skipped"`   // von Hand bearbeitete Wochen, die erhalten blieben
+}
+
+// GeneratorOptions steuert den Planvorschlag (GeneratePlanProposal)
+type GeneratorOptions struct {
+	Seed           int64              `json:"seed"`             // gleicher Seed = gleicher Vorschlag
+	Meals          []string           `json:"meals"`            // leer = alle Mahlzeiten
+	EntriesPerMeal int                `json:"entries_per_meal"` // Standard 1
+	NoRepeatDays   int                `json:"no_repeat_days"`   // Produkt frühestens nach N Tagen wieder
+	HistoryWeeks   int                `json:"history_weeks"`    // Wochen für Vorlieben aus der Verwendung, Standard 8
+	Filter         ProductFilter      `json:"filter"`           // Grundauswahl (z.B. nur vegetarisch)
+	Profiles       []GeneratorProfile `json:"profiles"`         // Gruppen mit Einschränkungen
+}
+
+// GeneratorProfile beschreibt eine Gruppe mit Einschränkungen (z.B. Krippe ohne Nüsse).
+// Passt ein vorgeschlagenes Produkt nicht zum Filter, wird für die Gruppe eine Alternative ergänzt
+// und das Produkt nur noch für die übrigen Gruppen (aus Kinderzahlen und Profilen) eingetragen.
+type GeneratorProfile struct {
+	GroupLabel string        `json:"group_label"`
+	Filter     ProductFilter `json:"filter"`
+}
+
+// AuditEntry repräsentiert einen Eintrag im Änderungsprotokoll
+type AuditEntry struct {
+	ID         int       `json:"id" db:"id"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	Actor      string    `json:"actor" db:"actor"`
+	Action     string    `json:"action" db:"action"` // 'create', 'update', 'delete', ...
+	Entity     string    `json:"entity" db:"entity"` // 'product', 'week_plan', 'plan_entry', 'special_day'
+	EntityID   int       `json:"entity_id" db:"entity_id"`
+	WeekPlanID *int      `json:"week_plan_id" db:"week_plan_id"`
+	Before     *string   `json:"before" db:"before"` // JSON-Zustand vor der Änderung
+	After      *string   `json:"after" db:"after"`   // JSON-Zustand nach der Änderung
+	Diff       string    `json:"diff" db:"diff"`     // JSON: Feld → {before, after}
+}
+
+// AuditChange repräsentiert die Änderung eines einzelnen Felds
+type AuditChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// AuditFilter schränkt das Änderungsprotokoll ein (leere Felder = kein Filter)
+type AuditFilter struct {
+	Entity     string `json:"entity"`
+	EntityID   *int   `json:"entity_id"`
+	WeekPlanID *int   `json:"week_plan_id"`
+	Actor      string `json:"actor"`
+	From       string `json:"from"` // YYYY-MM-DD, einschließlich
+	To         string `json:"to"`   // YYYY-MM-DD, einschließlich
+	Limit      int    `json:"limit"`
 }
 
 // UpdateInfo repräsentiert Informationen über verfügbare Updates
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/jmoiron/sqlx"
+)
+
+// NÄHRWERTE
+
+// SetProductNutrition setzt Portionsgröße und Nährwerte (je 100 g) eines Produkts.
+// nutrition == nil entfernt die Nährwertangaben.
+func (a *App) SetProductNutrition(productID int, portionSize *float64, nutrition *Nutrition) (*Product, error) {
+	if portionSize != nil && *portionSize <= 0 {
+		return nil, fmt.Errorf("portion size must be positive")
+	}
+
+	before, err := a.GetProduct(productID)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("UPDATE products SET portion_size = ? WHERE id = ?", portionSize, productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update portion size: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return nil, fmt.Errorf("product %d not found", productID)
+	}
+
+	_, err = tx.Exec("DELETE FROM product_nutrition WHERE product_id = ?", productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete old nutrition: %w", err)
+	}
+
+	if nutrition != nil {
+		_, err = tx.Exec(`
+			INSERT INTO product_nutrition (product_id, energy_kj, energy_kcal, protein, fat, saturated_fat, carbohydrates, sugar, fibre, salt)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, productID, nutrition.EnergyKJ, nutrition.EnergyKcal, nutrition.Protein, nutrition.Fat, nutrition.SaturatedFat,
+			nutrition.Carbohydrates, nutrition.Sugar, nutrition.Fibre, nutrition.Salt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to insert nutrition: %w", err)
+		}
+	}
+
+	after, err := a.auditProductUpdate(tx, before)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return after, nil
+}
+
+// GetNutritionSummary berechnet die Nährwertsummen je Tag und für die ganze Woche
+func (a *App) GetNutritionSummary(weekPlanID int) (*NutritionSummary, error) {
+	var plan WeekPlan
+	err := db.Get(&plan, "SELECT id, year, week, created_at FROM week_plans WHERE id = ?", weekPlanID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get week plan: %w", err)
+	}
+
+	entries, err := a.loadPlanEntries(db, plan.ID)
+	if err != nil {
+		return nil, err
+	}
+	plan.Entries = entries
+
+	days, err := operatingDays()
+	if err != nil {
+		return nil, err
+	}
+	return buildNutritionSummary(&plan, days), nil
+}
+
+// buildNutritionSummary summiert die Nährwerte je Portion über die Einträge eines Plans an den übergebenen Tagen
+func buildNutritionSummary(plan *WeekPlan, days []int) *NutritionSummary {
+	summary := &NutritionSummary{
+		WeekPlanID: plan.ID,
+		Year:       plan.Year,
+		Week:       plan.Week,
+	}
+
+	dayIndex := map[int]int{}
+	for _, day := range days {
+		dayIndex[day] = len(summary.Days)
+		summary.Days = append(summary.Days, DayNutrition{Day: day})
+	}
+
+	for _, e := range plan.Entries {
+		// Freitext-Einträge haben keine Nährwerte
+		if e.Product == nil {
+			continue
+		}
+		idx, ok := dayIndex[e.Day]
+		if !ok {
+			continue
+		}
+		dn := &summary.Days[idx]
+		if e.Product.NutritionPerPortion == nil {
+			dn.Missing++
+			summary.Missing++
+			continue
+		}
+		dn.Totals.add(e.Product.NutritionPerPortion)
+		dn.Counted++
+		summary.Totals.add(e.Product.NutritionPerPortion)
+	}
+
+	return summary
+}
+
+// loadProductNutrition lädt die Nährwerte eines Produkts und berechnet die Werte je Portion
+func loadProductNutrition(q sqlx.Queryer, product *Product) error {
+	var nutrition Nutrition
+	err := sqlx.Get(q, &nutrition, `
+		SELECT energy_kj, energy_kcal, protein, fat, saturated_fat, carbohydrates, sugar, fibre, salt
+		FROM product_nutrition
+		WHERE product_id = ?
+	`, product.ID)
+	if err == sql.ErrNoRows {
+		product.Nutrition = nil
+		product.NutritionPerPortion = nil
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load nutrition for product %d: %w", product.ID, err)
+	}
+
+	product.Nutrition = &nutrition
+	product.NutritionPerPortion = nil
+	if product.PortionSize != nil {
+		product.NutritionPerPortion = nutrition.scaled(*product.PortionSize / 100.0)
+	}
+	return nil
+}
+
+// fields gibt Zeiger auf alle Nährwertfelder in fester Reihenfolge zurück
+func (n *Nutrition) fields() []**float64 {
+	return []**float64{
+		&n.EnergyKJ, &n.EnergyKcal, &n.Protein, &n.Fat, &n.SaturatedFat,
+		&n.Carbohydrates, &n.Sugar, &n.Fibre, &n.Salt,
+	}
+}
+
+// scaled gibt eine mit factor multiplizierte Kopie zurück
+func (n *Nutrition) scaled(factor float64) *Nutrition {
+	out := &Nutrition{}
+	src := n.fields()
+	for i, dst := range out.fields() {
+		if *src[i] != nil {
+			v := **src[i] * factor
+			*dst = &v
+		}
+	}
+	return out
+}
+
+// add addiert die gesetzten Werte von other auf n
+func (n *Nutrition) add(other *Nutrition) {
+	src := other.fields()
+	for i, dst := range n.fields() {
+		if *src[i] == nil {
+			continue
+		}
+		if *dst == nil {
+			v := 0.0
+			*dst = &v
+		}
+		**dst += **src[i]
+	}
+}
+
+// drawNutritionTable zeichnet eine kompakte Nährwerttabelle (Zeilen = Tage, Spalten = Nährwerte)
+func drawNutritionTable(pdf *fpdf.Fpdf, summary *NutritionSummary, x, width float64) {
+	headers := []string{"", "kcal", "Eiweiß", "Fett", "ges. FS", "KH", "Zucker", "Ballastst.", "Salz"}
+	rowH := 3.5
+	firstW := 28.0
+	colW := (width - firstW) / float64(len(headers)-1)
+
+	cell := func(text string, w float64, align string, fill bool) {
+		pdf.CellFormat(w, rowH, text, "1", 0, align, fill, 0, "")
+	}
+
+	row := func(label string, n Nutrition, bold bool) {
+		pdf.SetX(x)
+		if bold {
+			pdf.SetFont("DejaVu", "B", 7)
+		} else {
+			pdf.SetFont("DejaVu", "", 7)
+		}
+		cell(label, firstW, "L", false)
+		values := []*float64{n.EnergyKcal, n.Protein, n.Fat, n.SaturatedFat, n.Carbohydrates, n.Sugar, n.Fibre, n.Salt}
+		for _, v := range values {
+			cell(formatNutritionValue(v), colW, "R", false)
+		}
+		pdf.Ln(rowH)
+	}
+
+	pdf.SetFont("DejaVu", "B", 8)
+	pdf.SetX(x)
+	pdf.CellFormat(0, 4, "Nährwerte je Portion (Summe):", "", 0, "L", false, 0, "")
+	pdf.Ln(4)
+
+	pdf.SetX(x)
+	pdf.SetFont("DejaVu", "B", 7)
+	pdf.SetFillColor(235, 235, 235)
+	for i, h := range headers {
+		if i == 0 {
+			cell(h, firstW, "L", true)
+		} else {
+			cell(h, colW, "C", true)
+		}
+	}
+	pdf.Ln(rowH)
+
+	for _, dn := range summary.Days {
+		label := weekdayName(dn.Day)
+		if dn.Missing > 0 {
+			label += "*"
+		}
+		row(label, dn.Totals, false)
+	}
+	row("Woche", summary.Totals, true)
+
+	if summary.Missing > 0 {
+		pdf.SetX(x)
+		pdf.SetFont("DejaVu", "", 6)
+		pdf.CellFormat(0, 3, fmt.Sprintf("* %d Einträge ohne Nährwertangaben nicht berücksichtigt", summary.Missing), "", 0, "L", false, 0, "")
+		pdf.Ln(3)
+	}
+}
+
+// nutritionTableHeight schätzt die Höhe der Nährwerttabelle in mm
+func nutritionTableHeight(summary *NutritionSummary) float64 {
+	h := 4 + 3.5*float64(len(summary.Days)+2)
+	if summary.Missing > 0 {
+		h += 3
+	}
+	return h
+}
+
+// formatNutritionValue formatiert einen Nährwert mit einer Nachkommastelle
+func formatNutritionValue(v *float64) string {
+	if v == nil {
+		return "–"
+	}
+	return fmt.Sprintf("%.1f", *v)
+}
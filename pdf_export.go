@@ -1,15 +1,32 @@
 package main
 
 import (
+	"encoding/csv"
 	"fmt"
+	"os"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/go-pdf/fpdf"
+
+	"speiseplan/isoweek"
 )
 
+// PDFOptions steuert optionale Bestandteile des PDF-Exports
+type PDFOptions struct {
+	IncludeNutrition bool `json:"include_nutrition"` // Nährwerttabelle unter der Legende
+	ColorByCategory  bool `json:"color_by_category"` // Farbmarkierung der Einträge nach Kategorie
+	Historical       bool `json:"historical"`        // Produkte im Stand zum Planungszeitpunkt
+}
+
 // ExportPDF exportiert einen Wochenplan als PDF im Querformat A4
 func (a *App) ExportPDF(weekPlanID int, outputPath string) error {
+	return a.ExportPDFWithOptions(weekPlanID, outputPath, PDFOptions{})
+}
+
+// ExportPDFWithOptions exportiert einen Wochenplan als PDF mit optionalen Zusatzinhalten
+func (a *App) ExportPDFWithOptions(weekPlanID int, outputPath string, opts PDFOptions) error {
 	// Plan aus DB laden
 	var plan WeekPlan
 	err := db.Get(&plan, "SELECT id, year, week, created_at FROM week_plans WHERE id = ?", weekPlanID)
@@ -17,29 +34,35 @@ func (a *App) ExportPDF(weekPlanID int, outputPath string) error {
 		return fmt.Errorf("Wochenplan nicht gefunden: %w", err)
 	}
 
-	entries, err := a.loadPlanEntries(plan.ID)
+	entries, err := a.loadPlanEntriesAt(db, plan.ID, opts.Historical)
 	if err != nil {
 		return err
 	}
 	plan.Entries = entries
 
-	specialDays, err := a.loadSpecialDays(plan.ID)
+	specialDays, err := a.loadSpecialDays(db, plan.ID)
 	if err != nil {
 		return err
 	}
 	plan.SpecialDays = specialDays
 
+	// Spalten = eingestellte Betriebstage
+	days, err := operatingDays()
+	if err != nil {
+		return err
+	}
+
 	// Montag der KW berechnen
-	monday := isoWeekMonday(plan.Year, plan.Week)
-	friday := monday.AddDate(0, 0, 4)
+	monday := isoweek.Monday(plan.Year, plan.Week)
+	firstDay := monday.AddDate(0, 0, days[0]-1)
+	lastDay := monday.AddDate(0, 0, days[len(days)-1]-1)
 
 	// PDF erstellen - Querformat A4
 	pdf := fpdf.New("L", "mm", "A4", "")
 	pdf.SetAutoPageBreak(false, 0)
 
 	// UTF-8 Font für deutsche Umlaute
-	pdf.AddUTF8Font("DejaVu", "", "/usr/share/fonts/truetype/dejavu/DejaVuSans.ttf")
-	pdf.AddUTF8Font("DejaVu", "B", "/usr/share/fonts/truetype/dejavu/DejaVuSans-Bold.ttf")
+	addReportFonts(pdf)
 
 	pdf.AddPage()
 
@@ -54,21 +77,14 @@ func (a *App) ExportPDF(weekPlanID int, outputPath string) error {
 	pdf.Ln(7)
 
 	pdf.SetFont("DejaVu", "", 10)
-	dateRange := fmt.Sprintf("%s – %s", monday.Format("02.01.2006"), friday.Format("02.01.2006"))
+	dateRange := fmt.Sprintf("%s – %s", firstDay.Format("02.01.2006"), lastDay.Format("02.01.2006"))
 	pdf.CellFormat(usableW, 6, dateRange, "", 0, "C", false, 0, "")
 	pdf.Ln(10)
 
 	tableTop := pdf.GetY()
 
 	// === TABELLE ===
-	colW := usableW / 5.0
-	dayNames := []string{"Montag", "Dienstag", "Mittwoch", "Donnerstag", "Freitag"}
-
-	// Sondertage-Map
-	specialMap := map[int]SpecialDay{}
-	for _, sd := range plan.SpecialDays {
-		specialMap[sd.Day] = sd
-	}
+	colW := usableW / float64(len(days))
 
 	// Einträge nach Tag+Meal gruppieren
 	type mealEntries struct {
@@ -76,8 +92,8 @@ func (a *App) ExportPDF(weekPlanID int, outputPath string) error {
 		vesper      []PlanEntry
 	}
 	dayEntries := map[int]*mealEntries{}
-	for i := 1; i <= 5; i++ {
-		dayEntries[i] = &mealEntries{}
+	for _, day := range days {
+		dayEntries[day] = &mealEntries{}
 	}
 	for _, e := range plan.Entries {
 		me := dayEntries[e.Day]
@@ -91,6 +107,16 @@ func (a *App) ExportPDF(weekPlanID int, outputPath string) error {
 		}
 	}
 
+	// Kategorie-Farben
+	var colors categoryColorResolver
+	usedCategories := map[int]Category{}
+	if opts.ColorByCategory {
+		colors, err = newCategoryColorResolver()
+		if err != nil {
+			return err
+		}
+	}
+
 	// Allergene & Zusatzstoffe sammeln
 	allergenSet := map[string]Allergen{}
 	additiveSet := map[string]Additive{}
@@ -107,11 +133,15 @@ func (a *App) ExportPDF(weekPlanID int, outputPath string) error {
 
 	// Kopfzeile: Tage
 	headerH := 12.0
-	pdf.SetFont("DejaVu", "B", 11)
+	headerFont := 11.0
+	if len(days) > 5 {
+		headerFont = 9
+	}
+	pdf.SetFont("DejaVu", "B", headerFont)
 	pdf.SetFillColor(220, 220, 220)
-	for i, name := range dayNames {
-		d := monday.AddDate(0, 0, i)
-		label := fmt.Sprintf("%s, %s", name, d.Format("02.01."))
+	for i, day := range days {
+		d := monday.AddDate(0, 0, day-1)
+		label := fmt.Sprintf("%s, %s", weekdayName(day), d.Format("02.01."))
 		x := marginX + float64(i)*colW
 		pdf.SetXY(x, tableTop)
 		pdf.CellFormat(colW, headerH, label, "1", 0, "C", true, 0, "")
@@ -122,8 +152,20 @@ func (a *App) ExportPDF(weekPlanID int, outputPath string) error {
 	mealLabels := []string{"Frühstück", "Vesper"}
 	mealKeys := []string{"fruehstueck", "vesper"}
 
+	// Nährwertübersicht
+	var nutrition *NutritionSummary
+	if opts.IncludeNutrition {
+		nutrition = buildNutritionSummary(&plan, days)
+	}
+
 	// Berechne verfügbare Höhe für die 2 Mahlzeit-Zeilen
 	legendEstimate := 30.0 // Platz für Legende + Footer
+	if nutrition != nil {
+		legendEstimate += nutritionTableHeight(nutrition)
+	}
+	if opts.ColorByCategory {
+		legendEstimate += 9 // Kategorie-Legende
+	}
 	availH := pageH - tableTop - headerH - legendEstimate
 	rowH := availH / 2.0
 	if rowH > 60 {
@@ -133,20 +175,16 @@ func (a *App) ExportPDF(weekPlanID int, outputPath string) error {
 	for mi, mealKey := range mealKeys {
 		rowTop := tableTop + headerH + float64(mi)*rowH
 
-		for day := 1; day <= 5; day++ {
-			x := marginX + float64(day-1)*colW
+		for i, day := range days {
+			x := marginX + float64(i)*colW
 
-			// Sondertag?
-			if sd, ok := specialMap[day]; ok {
+			// Sondertag für den ganzen Tag?
+			if sd := closingSpecial(plan.SpecialDays, day); sd != nil {
 				pdf.SetFillColor(200, 200, 200)
 				pdf.SetXY(x, rowTop)
 				pdf.SetFont("DejaVu", "B", 10)
-				label := sd.Type
-				if sd.Label != nil && *sd.Label != "" {
-					label = *sd.Label
-				}
 				if mi == 0 {
-					pdf.CellFormat(colW, rowH, label, "1", 0, "C", true, 0, "")
+					pdf.CellFormat(colW, rowH, sd.displayLabel(), "1", 0, "C", true, 0, "")
 				} else {
 					pdf.CellFormat(colW, rowH, "", "1", 0, "C", true, 0, "")
 				}
@@ -159,6 +197,15 @@ func (a *App) ExportPDF(weekPlanID int, outputPath string) error {
 			pdf.SetFont("DejaVu", "B", 9)
 			pdf.CellFormat(colW, 6, mealLabels[mi], "LTR", 0, "C", true, 0, "")
 
+			// Mahlzeit entfällt für alle Gruppen (z.B. schließt mittags, Lunchpaket)?
+			if sd := blockingSpecial(plan.SpecialDays, day, mealKey); sd != nil {
+				pdf.SetFillColor(200, 200, 200)
+				pdf.SetXY(x, rowTop+6)
+				pdf.SetFont("DejaVu", "B", 10)
+				pdf.CellFormat(colW, rowH-6, sd.displayLabel(), "LRB", 0, "C", true, 0, "")
+				continue
+			}
+
 			// Einträge
 			me := dayEntries[day]
 			var items []PlanEntry
@@ -173,8 +220,37 @@ func (a *App) ExportPDF(weekPlanID int, outputPath string) error {
 			contentH := rowH - 6
 			pdf.SetXY(x+1, contentTop)
 
+			// Sondertage einzelner Gruppen als Hinweis vor den Einträgen
+			for _, sd := range plan.SpecialDays {
+				if sd.Day != day || len(sd.Groups) == 0 || !sd.Meals.contains(mealKey) {
+					continue
+				}
+				pdf.SetFont("DejaVu", "B", 8)
+				pdf.SetX(x + 1)
+				pdf.MultiCell(colW-2, 4, fmt.Sprintf("[%s] %s", strings.Join(sd.Groups, ", "), sd.displayLabel()), "", "L", false)
+				pdf.SetFont("DejaVu", "", 9)
+			}
+
 			for _, item := range items {
+				if entryBlocked(plan.SpecialDays, day, mealKey, item.GroupLabel) {
+					continue
+				}
 				text := formatEntryText(item)
+
+				// Farbmarkierung links neben dem Eintrag
+				if colors != nil && item.Product != nil {
+					if color, category := colors.productColor(item.Product); category != nil {
+						r, g, b, _ := parseHexColor(color)
+						y := pdf.GetY()
+						pdf.SetX(x + 3)
+						pdf.MultiCell(colW-4, 4, text, "", "L", false)
+						pdf.SetFillColor(r, g, b)
+						pdf.Rect(x+1, y+0.5, 1.2, pdf.GetY()-y-1, "F")
+						usedCategories[category.ID] = *category
+						continue
+					}
+				}
+
 				pdf.SetX(x + 1)
 				pdf.MultiCell(colW-2, 4, text, "", "L", false)
 			}
@@ -214,6 +290,34 @@ func (a *App) ExportPDF(weekPlanID int, outputPath string) error {
 		pdf.MultiCell(usableW, 3.5, strings.Join(parts, "  |  "), "", "L", false)
 	}
 
+	// Kategorie-Legende
+	if len(usedCategories) > 0 {
+		pdf.Ln(1)
+		pdf.SetFont("DejaVu", "B", 8)
+		pdf.CellFormat(0, 4, "Kategorien:", "", 0, "L", false, 0, "")
+		pdf.Ln(4)
+		pdf.SetFont("DejaVu", "", 7)
+		var used []Category
+		for _, c := range usedCategories {
+			used = append(used, c)
+		}
+		sort.Slice(used, func(i, j int) bool { return used[i].SortOrder < used[j].SortOrder })
+		for _, c := range used {
+			r, g, b, _ := parseHexColor(*c.Color)
+			pdf.SetFillColor(r, g, b)
+			pdf.Rect(pdf.GetX(), pdf.GetY()+0.5, 2.5, 2.5, "F")
+			pdf.SetX(pdf.GetX() + 3.5)
+			pdf.CellFormat(pdf.GetStringWidth(c.Name)+5, 3.5, c.Name, "", 0, "L", false, 0, "")
+		}
+		pdf.Ln(4)
+	}
+
+	// === NÄHRWERTE ===
+	if nutrition != nil {
+		pdf.Ln(1)
+		drawNutritionTable(pdf, nutrition, marginX, usableW)
+	}
+
 	// === FOOTER ===
 	pdf.SetFont("DejaVu", "", 8)
 	footerY := pageH - 8
@@ -224,6 +328,45 @@ func (a *App) ExportPDF(weekPlanID int, outputPath string) error {
 	return pdf.OutputFileAndClose(outputPath)
 }
 
+// newReportPDF legt ein A4-Dokument für Berichte und Listen an: DejaVu-Schriften,
+// automatischer Seitenumbruch und Fußzeile mit Erstellungsdatum und Seitenzahl.
+// orientation ist "P" (Hochformat) oder "L" (Querformat).
+func newReportPDF(orientation string) *fpdf.Fpdf {
+	pdf := fpdf.New(orientation, "mm", "A4", "")
+	pdf.SetAutoPageBreak(true, 15)
+	addReportFonts(pdf)
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-10)
+		pdf.SetFont("DejaVu", "", 8)
+		pdf.CellFormat(0, 5, fmt.Sprintf("Erstellt am %s – Seite %d", time.Now().Format("02.01.2006"), pdf.PageNo()), "", 0, "R", false, 0, "")
+	})
+	return pdf
+}
+
+// addReportFonts bindet die DejaVu-Schriften (normal und fett) für deutsche Umlaute ein
+func addReportFonts(pdf *fpdf.Fpdf) {
+	pdf.AddUTF8Font("DejaVu", "", "/usr/share/fonts/truetype/dejavu/DejaVuSans.ttf")
+	pdf.AddUTF8Font("DejaVu", "B", "/usr/share/fonts/truetype/dejavu/DejaVuSans-Bold.ttf")
+}
+
+// newExcelCSV legt eine CSV-Datei an, die Excel direkt öffnen kann: UTF-8 mit BOM und
+// Semikolon als Trennzeichen. Der Aufrufer schreibt, ruft Flush auf und schließt die Datei.
+func newExcelCSV(path string) (*os.File, *csv.Writer, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create CSV file: %w", err)
+	}
+	// BOM, damit Excel UTF-8 erkennt
+	if _, err := f.WriteString("\ufeff"); err != nil {
+		f.Close()
+		return nil, nil, fmt.Errorf("failed to write CSV file: %w", err)
+	}
+
+	w := csv.NewWriter(f)
+	w.Comma = ';'
+	return f, w, nil
+}
+
 // formatEntryText formatiert einen PlanEntry für die PDF-Ausgabe
 func formatEntryText(e PlanEntry) string {
 	var text string
@@ -258,18 +401,3 @@ func formatEntryText(e PlanEntry) string {
 
 	return text
 }
-
-// isoWeekMonday gibt den Montag der ISO-Kalenderwoche zurück
-func isoWeekMonday(year, week int) time.Time {
-	// 4. Januar liegt immer in KW 1
-	jan4 := time.Date(year, 1, 4, 0, 0, 0, 0, time.Local)
-	// Wochentag von 4. Jan (0=So, 1=Mo, ...)
-	weekday := int(jan4.Weekday())
-	if weekday == 0 {
-		weekday = 7
-	}
-	// Montag der KW 1
-	mondayKW1 := jan4.AddDate(0, 0, -(weekday - 1))
-	// Montag der gewünschten KW
-	return mondayKW1.AddDate(0, 0, (week-1)*7)
-}
@@ -0,0 +1,324 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// LÖSCHEN
+
+// PrepareWeekPlanDeletion zeigt an, was beim Löschen eines Wochenplans entfernt wird, und
+// gibt das Bestätigungstoken für DeleteWeekPlan zurück. Ändert sich der Plan danach,
+// verliert das Token seine Gültigkeit.
+func (a *App) PrepareWeekPlanDeletion(weekPlanID int) (*WeekPlanDeletion, error) {
+	if err := ensurePlanEditable(weekPlanID); err != nil {
+		return nil, err
+	}
+	plan, err := a.getWeekPlanByID(db, weekPlanID)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := weekPlanDeletionToken(plan)
+	if err != nil {
+		return nil, err
+	}
+	return &WeekPlanDeletion{
+		WeekPlanID:  plan.ID,
+		Year:        plan.Year,
+		Week:        plan.Week,
+		Entries:     len(plan.Entries),
+		SpecialDays: len(plan.SpecialDays),
+		Token:       token,
+	}, nil
+}
+
+// DeleteWeekPlan löscht einen Wochenplan samt Einträgen und Sondertagen, nachdem er als
+// JSON gesichert wurde. token muss aus PrepareWeekPlanDeletion stammen.
+func (a *App) DeleteWeekPlan(weekPlanID int, token string) (*WeekPlanBackup, error) {
+	if err := ensurePlanEditable(weekPlanID); err != nil {
+		return nil, err
+	}
+	plan, err := a.getWeekPlanByID(db, weekPlanID)
+	if err != nil {
+		return nil, err
+	}
+
+	expected, err := weekPlanDeletionToken(plan)
+	if err != nil {
+		return nil, err
+	}
+	if token != expected {
+		return nil, fmt.Errorf("confirmation token does not match; the week plan may have changed since deletion was requested")
+	}
+
+	data, err := json.Marshal(plan)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize week plan: %w", err)
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		INSERT INTO week_plan_backups (week_plan_id, year, week, deleted_at, deleted_by, data)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, plan.ID, plan.Year, plan.Week, time.Now().Format(auditTimeLayout), a.currentActor(), string(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to back up week plan: %w", err)
+	}
+	backupID, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get backup ID: %w", err)
+	}
+
+	// Abhängige Zeilen ausdrücklich entfernen, statt sich auf ON DELETE CASCADE zu verlassen
+	children := []string{
+		"DELETE FROM plan_entries WHERE week_plan_id = ?",
+		"DELETE FROM special_days WHERE week_plan_id = ?",
+		"DELETE FROM headcounts WHERE week_plan_id = ?",
+		"UPDATE stock_movements SET week_plan_id = NULL WHERE week_plan_id = ?",
+	}
+	for _, query := range children {
+		if _, err := tx.Exec(query, weekPlanID); err != nil {
+			return nil, fmt.Errorf("failed to delete week plan data: %w", err)
+		}
+	}
+	if _, err := tx.Exec("DELETE FROM week_plans WHERE id = ?", weekPlanID); err != nil {
+		return nil, fmt.Errorf("failed to delete week plan: %w", err)
+	}
+	if err := a.audit(tx, auditActionDelete, auditEntityWeekPlan, weekPlanID, &weekPlanID, plan, nil); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	a.history.forgetPlan(weekPlanID)
+	return getWeekPlanBackup(int(backupID))
+}
+
+// GetWeekPlanBackups gibt die Sicherungen gelöschter Wochenpläne zurück, neueste zuerst
+func (a *App) GetWeekPlanBackups() ([]WeekPlanBackup, error) {
+	backups := []WeekPlanBackup{}
+	err := db.Select(&backups, `
+		SELECT id, week_plan_id, year, week, deleted_at, deleted_by, data
+		FROM week_plan_backups
+		ORDER BY deleted_at DESC, id DESC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get week plan backups: %w", err)
+	}
+	return backups, nil
+}
+
+// RestoreWeekPlanBackup legt einen gelöschten Wochenplan aus seiner Sicherung neu an.
+// Die Kalenderwoche darf dafür keinen Plan haben.
+func (a *App) RestoreWeekPlanBackup(backupID int) (*WeekPlan, error) {
+	backup, err := getWeekPlanBackup(backupID)
+	if err != nil {
+		return nil, err
+	}
+	var saved WeekPlan
+	if err := json.Unmarshal([]byte(backup.Data), &saved); err != nil {
+		return nil, fmt.Errorf("failed to read week plan backup: %w", err)
+	}
+
+	var exists int
+	if err := db.Get(&exists, "SELECT COUNT(*) FROM week_plans WHERE year = ? AND week = ?", saved.Year, saved.Week); err != nil {
+		return nil, fmt.Errorf("failed to check target week: %w", err)
+	}
+	if exists > 0 {
+		return nil, fmt.Errorf("week %d/%d already has a plan", saved.Week, saved.Year)
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Freigabe und Archivierung werden nicht übernommen; der Plan startet als Entwurf
+	result, err := tx.Exec("INSERT INTO week_plans (year, week) VALUES (?, ?)", saved.Year, saved.Week)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create week plan: %w", err)
+	}
+	newID, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get week plan ID: %w", err)
+	}
+	weekPlanID := int(newID)
+
+	for _, e := range saved.Entries {
+		// Gelöschte Produkte werden als Freitext übernommen
+		productID := e.ProductID
+		customText := e.CustomText
+		if productID != nil {
+			var count int
+			if err := tx.Get(&count, "SELECT COUNT(*) FROM products WHERE id = ?", *productID); err != nil {
+				return nil, fmt.Errorf("failed to check product: %w", err)
+			}
+			if count == 0 {
+				if e.Product != nil {
+					name := e.Product.Name
+					customText = &name
+				}
+				productID = nil
+			}
+		}
+
+		_, err := tx.Exec(`
+			INSERT INTO plan_entries (week_plan_id, day, meal, slot, product_id, product_revision_id, custom_text, group_label)
+			VALUES (?, ?, ?, ?, ?, `+currentRevisionSQL+`, ?, ?)
+		`, weekPlanID, e.Day, e.Meal, e.Slot, productID, productID, customText, e.GroupLabel)
+		if err != nil {
+			return nil, fmt.Errorf("failed to restore plan entry: %w", err)
+		}
+	}
+	if err := renumberSlots(tx, "week_plan_id = ?", weekPlanID); err != nil {
+		return nil, err
+	}
+
+	for _, sd := range saved.SpecialDays {
+		_, err := tx.Exec("INSERT INTO special_days (week_plan_id, day, type, label, meals, groups) VALUES (?, ?, ?, ?, ?, ?)",
+			weekPlanID, sd.Day, sd.Type, sd.Label, sd.Meals, sd.Groups)
+		if err != nil {
+			return nil, fmt.Errorf("failed to restore special day: %w", err)
+		}
+	}
+
+	plan, err := a.getWeekPlanByID(tx, weekPlanID)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.audit(tx, auditActionCreate, auditEntityWeekPlan, plan.ID, &plan.ID, nil, plan); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return plan, nil
+}
+
+// ARCHIV
+
+// ArchiveWeekPlan archiviert einen Wochenplan; archivierte Pläne sind schreibgeschützt
+func (a *App) ArchiveWeekPlan(weekPlanID int) (*WeekPlan, error) {
+	return a.setWeekPlanArchived(weekPlanID, true)
+}
+
+// UnarchiveWeekPlan holt einen Wochenplan aus dem Archiv zurück
+func (a *App) UnarchiveWeekPlan(weekPlanID int) (*WeekPlan, error) {
+	return a.setWeekPlanArchived(weekPlanID, false)
+}
+
+// LEEREN
+
+// ClearWeekPlan entfernt alle Einträge eines Wochenplans; Plan und Sondertage bleiben erhalten.
+// Lässt sich wie jede Planänderung rückgängig machen.
+func (a *App) ClearWeekPlan(weekPlanID int) (*WeekPlan, error) {
+	if err := ensurePlanEditable(weekPlanID); err != nil {
+		return nil, err
+	}
+	before, err := a.loadPlanEntries(db, weekPlanID)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM plan_entries WHERE week_plan_id = ?", weekPlanID); err != nil {
+		return nil, fmt.Errorf("failed to clear week plan: %w", err)
+	}
+
+	change := planChange{weekPlanID: weekPlanID}
+	for i := range before {
+		entry := &before[i]
+		if err := a.audit(tx, auditActionDelete, auditEntityPlanEntry, entry.ID, &weekPlanID, entry, nil); err != nil {
+			return nil, err
+		}
+		change.entries = append(change.entries, entryChange{id: entry.ID, before: entry})
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	if len(change.entries) > 0 {
+		a.recordPlanChange(change)
+	}
+
+	return a.getWeekPlanByID(db, weekPlanID)
+}
+
+// HILFSFUNKTIONEN
+
+// setWeekPlanArchived setzt das Archiv-Flag eines Wochenplans
+func (a *App) setWeekPlanArchived(weekPlanID int, archived bool) (*WeekPlan, error) {
+	before, err := a.getWeekPlanByID(db, weekPlanID)
+	if err != nil {
+		return nil, err
+	}
+	if before.Archived == archived {
+		return before, nil
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("UPDATE week_plans SET archived = ? WHERE id = ?", archived, weekPlanID); err != nil {
+		return nil, fmt.Errorf("failed to update week plan archive state: %w", err)
+	}
+
+	after, err := a.getWeekPlanByID(tx, weekPlanID)
+	if err != nil {
+		return nil, err
+	}
+	err = a.audit(tx, auditActionUpdate, auditEntityWeekPlan, weekPlanID, &weekPlanID,
+		map[string]interface{}{"archived": before.Archived}, map[string]interface{}{"archived": after.Archived})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return after, nil
+}
+
+// weekPlanDeletionToken leitet das Bestätigungstoken aus dem aktuellen Stand eines Plans ab
+func weekPlanDeletionToken(plan *WeekPlan) (string, error) {
+	fingerprint, err := planFingerprint(db, plan.ID)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%d|%d|%d|%s|%d", plan.ID, plan.Year, plan.Week, fingerprint, len(plan.SpecialDays))))
+	return hex.EncodeToString(sum[:6]), nil
+}
+
+// getWeekPlanBackup lädt eine Sicherung über ihre ID
+func getWeekPlanBackup(id int) (*WeekPlanBackup, error) {
+	var backup WeekPlanBackup
+	err := db.Get(&backup, `
+		SELECT id, week_plan_id, year, week, deleted_at, deleted_by, data
+		FROM week_plan_backups
+		WHERE id = ?
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get week plan backup: %w", err)
+	}
+	return &backup, nil
+}
@@ -0,0 +1,512 @@
+package main
+
+import (
+	"fmt"
+)
+
+// planMeals sind die Mahlzeiten eines Tages in Anzeigereihenfolge
+var planMeals = []string{"fruehstueck", "vesper"}
+
+// planMealNames sind die Anzeigenamen der Mahlzeiten
+var planMealNames = map[string]string{"fruehstueck": "Frühstück", "vesper": "Vesper"}
+
+// Modi für CopyPlanSection
+const (
+	copyModeAppend  = "append"
+	copyModeReplace = "replace"
+	copyModeSkip    = "skip"
+)
+
+// Konfliktmodi für CopyWeekPlanWithMode, wenn die Zielwoche bereits existiert
+const (
+	copyConflictFail      = "fail"      // abbrechen
+	copyConflictOverwrite = "overwrite" // Zielwoche vollständig ersetzen
+	copyConflictMerge     = "merge"     // nur fehlende Einträge und Sondertage ergänzen
+)
+
+// KOPIEREN
+
+// CopyPlanSection kopiert einen Ausschnitt (Woche, Tage oder eine Mahlzeit) in einen
+// anderen oder denselben Wochenplan. target.Day ist der erste Zieltag (0 = dieselben
+// Tage), target.Meal die Zielmahlzeit (leer = dieselbe). Der Zielplan wird bei Bedarf
+// angelegt; alles läuft in einer Transaktion. Einträge, die ein Sondertag im Ziel
+// sperrt, werden nicht angelegt und in CopyResult.Blocked zurückgegeben.
+func (a *App) CopyPlanSection(source PlanSelection, target PlanSelection, opts CopyOptions) (*CopyResult, error) {
+	mode := opts.Mode
+	if mode == "" {
+		mode = copyModeAppend
+	}
+	if mode != copyModeAppend && mode != copyModeReplace && mode != copyModeSkip {
+		return nil, fmt.Errorf("invalid copy mode %q", opts.Mode)
+	}
+
+	days, err := selectionDays(source)
+	if err != nil {
+		return nil, err
+	}
+	offset := 0
+	if target.Day != 0 {
+		if source.Day == 0 {
+			return nil, fmt.Errorf("a whole week can only be copied to a whole week")
+		}
+		offset = target.Day - days[0]
+	}
+	for _, day := range days {
+		if err := validateOperatingDay(day + offset); err != nil {
+			return nil, fmt.Errorf("invalid target range: %w", err)
+		}
+	}
+
+	meals := planMeals
+	if source.Meal != "" {
+		meals = []string{source.Meal}
+	} else if target.Meal != "" {
+		return nil, fmt.Errorf("all meals can only be copied to all meals")
+	}
+	for _, meal := range []string{source.Meal, target.Meal} {
+		if meal == "" {
+			continue
+		}
+		if err := validatePlanMeal(meal); err != nil {
+			return nil, err
+		}
+	}
+	targetMeal := func(meal string) string {
+		if target.Meal != "" {
+			return target.Meal
+		}
+		return meal
+	}
+
+	sourcePlan, err := a.GetWeekPlan(source.Year, source.Week)
+	if err != nil {
+		return nil, err
+	}
+	targetPlan, err := a.GetWeekPlan(target.Year, target.Week)
+	if err != nil && !isNotFound(err) {
+		return nil, err
+	}
+	if targetPlan != nil {
+		if err := ensurePlanEditable(targetPlan.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result := &CopyResult{Copied: []PlanEntry{}, Skipped: []PlanEntry{}, Blocked: []PlanEntry{}}
+
+	// Fehlender Zielplan wird in derselben Transaktion angelegt und verschwindet bei einem Fehler wieder
+	if targetPlan == nil {
+		targetPlan, err = a.createWeekPlan(tx, target.Year, target.Week)
+		if err != nil {
+			return nil, err
+		}
+		result.Created = true
+	}
+
+	change := planChange{weekPlanID: targetPlan.ID}
+	var createdIDs []int
+	targetSpecials := append([]SpecialDay{}, targetPlan.SpecialDays...)
+
+	for _, day := range days {
+		toDay := day + offset
+
+		// Sondertage zuerst übernehmen, damit sie beim Einfügen der Einträge schon gelten;
+		// solche mit gleichem Bereich am Zieltag werden ersetzt bzw. übersprungen
+		for _, special := range sourcePlan.SpecialDays {
+			if !opts.IncludeSpecialDays || special.Day != day {
+				continue
+			}
+			special.Day = toDay
+			var existing *SpecialDay
+			existingIdx := -1
+			for i := range targetSpecials {
+				if targetSpecials[i].scopeKey() == special.scopeKey() {
+					existing = &targetSpecials[i]
+					existingIdx = i
+				}
+			}
+			if mode == copyModeSkip && existing != nil {
+				result.SpecialDaysSkipped++
+				continue
+			}
+			_, err := tx.Exec("DELETE FROM special_days WHERE week_plan_id = ? AND day = ? AND meals = ? AND groups = ?",
+				targetPlan.ID, toDay, special.Meals, special.Groups)
+			if err != nil {
+				return nil, fmt.Errorf("failed to replace special day: %w", err)
+			}
+			res, err := tx.Exec(`
+				INSERT INTO special_days (week_plan_id, day, type, label, meals, groups)
+				VALUES (?, ?, ?, ?, ?, ?)
+			`, targetPlan.ID, toDay, special.Type, special.Label, special.Meals, special.Groups)
+			if err != nil {
+				return nil, fmt.Errorf("failed to copy special day: %w", err)
+			}
+			id, err := res.LastInsertId()
+			if err != nil {
+				return nil, fmt.Errorf("failed to get special day ID: %w", err)
+			}
+			after := SpecialDay{ID: int(id), WeekPlanID: targetPlan.ID, Day: toDay, Type: special.Type, Label: special.Label, Meals: special.Meals, Groups: special.Groups}
+			var before *SpecialDay
+			if existing != nil {
+				prev := *existing
+				before = &prev
+				targetSpecials[existingIdx] = after
+			} else {
+				targetSpecials = append(targetSpecials, after)
+			}
+			change.specials = append(change.specials, newSpecialChange(before, &after))
+			result.SpecialDaysCopied++
+		}
+
+		for _, meal := range meals {
+			toMeal := targetMeal(meal)
+
+			var existing []PlanEntry
+			for _, e := range targetPlan.Entries {
+				if e.Day == toDay && e.Meal == toMeal {
+					existing = append(existing, e)
+				}
+			}
+			var sourceEntries []PlanEntry
+			for _, entry := range sourcePlan.Entries {
+				if entry.Day == day && entry.Meal == meal {
+					sourceEntries = append(sourceEntries, entry)
+				}
+			}
+			if mode == copyModeSkip && len(existing) > 0 {
+				result.Skipped = append(result.Skipped, sourceEntries...)
+				continue
+			}
+
+			slot := len(existing)
+			if mode == copyModeReplace {
+				for i := range existing {
+					if _, err := tx.Exec("DELETE FROM plan_entries WHERE id = ?", existing[i].ID); err != nil {
+						return nil, fmt.Errorf("failed to remove plan entry: %w", err)
+					}
+					change.entries = append(change.entries, entryChange{id: existing[i].ID, before: &existing[i]})
+				}
+				result.Removed += len(existing)
+				slot = 0
+			}
+
+			for _, entry := range sourceEntries {
+				if entryBlocked(targetSpecials, toDay, toMeal, entry.GroupLabel) {
+					result.Blocked = append(result.Blocked, entry)
+					continue
+				}
+				res, err := tx.Exec(`
+					INSERT INTO plan_entries (week_plan_id, day, meal, slot, product_id, product_revision_id, custom_text, group_label)
+					VALUES (?, ?, ?, ?, ?, `+currentRevisionSQL+`, ?, ?)
+				`, targetPlan.ID, toDay, toMeal, slot, entry.ProductID, entry.ProductID, entry.CustomText, entry.GroupLabel)
+				if err != nil {
+					return nil, fmt.Errorf("failed to copy plan entry: %w", err)
+				}
+				id, err := res.LastInsertId()
+				if err != nil {
+					return nil, fmt.Errorf("failed to get entry ID: %w", err)
+				}
+				createdIDs = append(createdIDs, int(id))
+				slot++
+			}
+		}
+	}
+
+	for _, id := range createdIDs {
+		entry, err := a.getPlanEntry(tx, id)
+		if err != nil {
+			return nil, err
+		}
+		result.Copied = append(result.Copied, *entry)
+		change.entries = append(change.entries, entryChange{id: id, after: entry})
+	}
+	for _, c := range change.entries {
+		action := auditActionCreate
+		if c.after == nil {
+			action = auditActionDelete
+		}
+		if err := a.audit(tx, action, auditEntityPlanEntry, c.id, &targetPlan.ID, c.before, c.after); err != nil {
+			return nil, err
+		}
+	}
+	for _, c := range change.specials {
+		action := auditActionCreate
+		if c.before != nil {
+			action = auditActionUpdate
+		}
+		if err := a.audit(tx, action, auditEntitySpecialDay, c.after.ID, &targetPlan.ID, c.before, c.after); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	if len(change.entries) > 0 || len(change.specials) > 0 {
+		a.recordPlanChange(change)
+	}
+
+	result.Plan, err = a.getWeekPlanByID(db, targetPlan.ID)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// CopyWeekPlanWithMode kopiert einen ganzen Wochenplan in einer Transaktion.
+// Existiert die Zielwoche, entscheidet mode: "fail" bricht ab, "overwrite" ersetzt
+// Einträge und Sondertage, "merge" ergänzt nur, was im Ziel noch fehlt.
+func (a *App) CopyWeekPlanWithMode(sourceYear int, sourceWeek int, targetYear int, targetWeek int, mode string) (*CopyResult, error) {
+	mode, err := validateConflictMode(mode)
+	if err != nil {
+		return nil, err
+	}
+	if sourceYear == targetYear && sourceWeek == targetWeek {
+		return nil, fmt.Errorf("source and target week are the same")
+	}
+
+	// Quelle zuerst prüfen, damit kein leerer Zielplan zurückbleibt
+	sourcePlan, err := a.GetWeekPlan(sourceYear, sourceWeek)
+	if isNotFound(err) {
+		return nil, fmt.Errorf("source week %d/%d does not exist", sourceWeek, sourceYear)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return a.copyIntoWeek(sourcePlan.Entries, sourcePlan.SpecialDays, true, targetYear, targetWeek, mode)
+}
+
+// HILFSFUNKTIONEN
+
+// copyIntoWeek überträgt Einträge und Sondertage in einer Transaktion in eine
+// Kalenderwoche (Konfliktmodi wie CopyWeekPlanWithMode, mode ist bereits geprüft).
+// Nur bei replaceSpecials entfernt "overwrite" auch die Sondertage des Ziels; Einträge an
+// Tagen ohne Verpflegung oder wegen eines Sondertags im Ziel werden nicht angelegt.
+func (a *App) copyIntoWeek(entries []PlanEntry, specials []SpecialDay, replaceSpecials bool, targetYear int, targetWeek int, mode string) (*CopyResult, error) {
+	targetPlan, err := a.GetWeekPlan(targetYear, targetWeek)
+	if err != nil && !isNotFound(err) {
+		return nil, err
+	}
+	if targetPlan != nil && mode == copyConflictFail {
+		return nil, fmt.Errorf("target week %d/%d already exists", targetWeek, targetYear)
+	}
+	if targetPlan != nil {
+		if err := ensurePlanEditable(targetPlan.ID); err != nil {
+			return nil, err
+		}
+	}
+	operating, err := operatingDaySet()
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result := &CopyResult{Copied: []PlanEntry{}, Skipped: []PlanEntry{}, Blocked: []PlanEntry{}}
+	if targetPlan == nil {
+		res, err := tx.Exec("INSERT INTO week_plans (year, week) VALUES (?, ?)", targetYear, targetWeek)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create week plan: %w", err)
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get week plan ID: %w", err)
+		}
+		targetPlan = &WeekPlan{ID: int(id), Year: targetYear, Week: targetWeek}
+		result.Created = true
+	}
+
+	change := planChange{weekPlanID: targetPlan.ID}
+
+	// Überschreiben: bestehende Einträge (und ggf. Sondertage) entfernen
+	existingEntries := targetPlan.Entries
+	existingSpecials := targetPlan.SpecialDays
+	if mode == copyConflictOverwrite {
+		for i := range existingEntries {
+			change.entries = append(change.entries, entryChange{id: existingEntries[i].ID, before: &existingEntries[i]})
+		}
+		if _, err := tx.Exec("DELETE FROM plan_entries WHERE week_plan_id = ?", targetPlan.ID); err != nil {
+			return nil, fmt.Errorf("failed to clear target entries: %w", err)
+		}
+		result.Removed = len(existingEntries)
+		existingEntries = nil
+
+		if replaceSpecials {
+			for i := range existingSpecials {
+				change.specials = append(change.specials, newSpecialChange(&existingSpecials[i], nil))
+			}
+			if _, err := tx.Exec("DELETE FROM special_days WHERE week_plan_id = ?", targetPlan.ID); err != nil {
+				return nil, fmt.Errorf("failed to clear target special days: %w", err)
+			}
+			existingSpecials = nil
+		}
+	}
+
+	// Sondertage zuerst übernehmen, damit sie beim Einfügen der Einträge schon gelten
+	targetSpecials := append([]SpecialDay{}, existingSpecials...)
+	hasSpecial := map[string]bool{}
+	for _, special := range existingSpecials {
+		hasSpecial[special.scopeKey()] = true
+	}
+	for _, special := range specials {
+		if hasSpecial[special.scopeKey()] {
+			result.SpecialDaysSkipped++
+			continue
+		}
+		res, err := tx.Exec(`
+			INSERT INTO special_days (week_plan_id, day, type, label, meals, groups)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`, targetPlan.ID, special.Day, special.Type, special.Label, special.Meals, special.Groups)
+		if err != nil {
+			return nil, fmt.Errorf("failed to copy special day: %w", err)
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get special day ID: %w", err)
+		}
+		hasSpecial[special.scopeKey()] = true
+		result.SpecialDaysCopied++
+
+		after := SpecialDay{ID: int(id), WeekPlanID: targetPlan.ID, Day: special.Day, Type: special.Type, Label: special.Label, Meals: special.Meals, Groups: special.Groups}
+		targetSpecials = append(targetSpecials, after)
+		merged := false
+		for i := range change.specials {
+			if change.specials[i].before != nil && change.specials[i].before.scopeKey() == after.scopeKey() {
+				change.specials[i].after = &after
+				merged = true
+			}
+		}
+		if !merged {
+			change.specials = append(change.specials, newSpecialChange(nil, &after))
+		}
+	}
+
+	// Vorhandene Einträge je Tag/Mahlzeit: nächste freie Position und Inhalte für den Abgleich
+	nextSlot := map[string]int{}
+	present := map[string]bool{}
+	for _, e := range existingEntries {
+		group := fmt.Sprintf("%d/%s", e.Day, e.Meal)
+		if e.Slot+1 > nextSlot[group] {
+			nextSlot[group] = e.Slot + 1
+		}
+		present[planEntryKey(e)] = true
+	}
+
+	var createdIDs []int
+	for _, entry := range entries {
+		if present[planEntryKey(entry)] {
+			result.Skipped = append(result.Skipped, entry)
+			continue
+		}
+		if !operating[entry.Day] || entryBlocked(targetSpecials, entry.Day, entry.Meal, entry.GroupLabel) {
+			result.Blocked = append(result.Blocked, entry)
+			continue
+		}
+
+		group := fmt.Sprintf("%d/%s", entry.Day, entry.Meal)
+		res, err := tx.Exec(`
+			INSERT INTO plan_entries (week_plan_id, day, meal, slot, product_id, product_revision_id, custom_text, group_label)
+			VALUES (?, ?, ?, ?, ?, `+currentRevisionSQL+`, ?, ?)
+		`, targetPlan.ID, entry.Day, entry.Meal, nextSlot[group], entry.ProductID, entry.ProductID, entry.CustomText, entry.GroupLabel)
+		if err != nil {
+			return nil, fmt.Errorf("failed to copy plan entry: %w", err)
+		}
+		nextSlot[group]++
+
+		id, err := res.LastInsertId()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get entry ID: %w", err)
+		}
+		createdIDs = append(createdIDs, int(id))
+	}
+
+	for _, id := range createdIDs {
+		entry, err := a.getPlanEntry(tx, id)
+		if err != nil {
+			return nil, err
+		}
+		result.Copied = append(result.Copied, *entry)
+		change.entries = append(change.entries, entryChange{id: id, after: entry})
+	}
+
+	result.Plan, err = a.getWeekPlanByID(tx, targetPlan.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Created {
+		err = a.audit(tx, auditActionCreate, auditEntityWeekPlan, targetPlan.ID, &targetPlan.ID, nil, result.Plan)
+	} else {
+		err = a.audit(tx, auditActionCopy, auditEntityWeekPlan, targetPlan.ID, &targetPlan.ID, targetPlan, result.Plan)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	if len(change.entries) > 0 || len(change.specials) > 0 {
+		a.recordPlanChange(change)
+	}
+
+	return result, nil
+}
+
+// validateConflictMode prüft einen Konfliktmodus; leer bedeutet "fail"
+func validateConflictMode(mode string) (string, error) {
+	switch mode {
+	case "":
+		return copyConflictFail, nil
+	case copyConflictFail, copyConflictOverwrite, copyConflictMerge:
+		return mode, nil
+	}
+	return "", fmt.Errorf("invalid conflict mode %q", mode)
+}
+
+// planEntryKey beschreibt den Inhalt eines Eintrags an seinem Tag/Mahlzeit für den Abgleich beim Zusammenführen
+func planEntryKey(e PlanEntry) string {
+	content := ""
+	if e.ProductID != nil {
+		content = fmt.Sprintf("p%d", *e.ProductID)
+	} else if e.CustomText != nil {
+		content = "t" + *e.CustomText
+	}
+	group := ""
+	if e.GroupLabel != nil {
+		group = *e.GroupLabel
+	}
+	return fmt.Sprintf("%d/%s/%s/%s", e.Day, e.Meal, content, group)
+}
+
+// selectionDays gibt die Tage eines Planausschnitts zurück; eine ganze Woche umfasst
+// die Betriebstage, ausgewählte Tage müssen Betriebstage sein
+func selectionDays(sel PlanSelection) ([]int, error) {
+	if sel.Day == 0 {
+		return operatingDays()
+	}
+
+	count := sel.Days
+	if count < 1 {
+		count = 1
+	}
+	var days []int
+	for day := sel.Day; day < sel.Day+count; day++ {
+		if err := validateOperatingDay(day); err != nil {
+			return nil, err
+		}
+		days = append(days, day)
+	}
+	return days, nil
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"speiseplan/isoweek"
+)
+
+// KALENDERDATEN
+
+// GetWeekForDate gibt die ISO-Kalenderwoche zurück, in der ein Datum (YYYY-MM-DD) liegt
+func (a *App) GetWeekForDate(date string) (*WeekRef, error) {
+	t, err := isoweek.ParseDate(date)
+	if err != nil {
+		return nil, err
+	}
+	year, week, _ := isoweek.Of(t)
+	return &WeekRef{Year: year, Week: week}, nil
+}
+
+// GetPlanForDate gibt den Wochenplan der Kalenderwoche zurück, in der ein Datum (YYYY-MM-DD) liegt
+func (a *App) GetPlanForDate(date string) (*WeekPlan, error) {
+	ref, err := a.GetWeekForDate(date)
+	if err != nil {
+		return nil, err
+	}
+	return a.GetWeekPlan(ref.Year, ref.Week)
+}
+
+// GetPlansInRange gibt alle vorhandenen Wochenpläne zurück, die den Zeitraum from–to
+// (YYYY-MM-DD, jeweils einschließlich) berühren; Wochen ohne Plan werden ausgelassen
+func (a *App) GetPlansInRange(from string, to string) ([]WeekPlan, error) {
+	weeks, err := weeksInRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	plans := []WeekPlan{}
+	for _, ref := range weeks {
+		plan, err := a.GetWeekPlan(ref.Year, ref.Week)
+		if err != nil {
+			if isNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		plans = append(plans, *plan)
+	}
+	return plans, nil
+}
+
+// HILFSFUNKTIONEN
+
+// validateWeek prüft, ob es die Kalenderwoche im ISO-Jahr gibt (KW 53 nur in langen Jahren)
+func validateWeek(year int, week int) error {
+	if !isoweek.Valid(year, week) {
+		return fmt.Errorf("invalid calendar week %d/%d", week, year)
+	}
+	return nil
+}
+
+// planDays gibt die Kalendertage Mo–So einer Kalenderwoche mit Datum und Betriebstag-Kennung zurück
+func planDays(year int, week int) ([]PlanDay, error) {
+	operating, err := operatingDays()
+	if err != nil {
+		return nil, err
+	}
+	open := map[int]bool{}
+	for _, day := range operating {
+		open[day] = true
+	}
+
+	days := make([]PlanDay, 0, 7)
+	for day := 1; day <= 7; day++ {
+		days = append(days, PlanDay{
+			Day:       day,
+			Date:      isoweek.FormatDate(isoweek.Day(year, week, day)),
+			Name:      weekdayName(day),
+			Operating: open[day],
+		})
+	}
+	return days, nil
+}
+
+// publicHolidays gibt die bundesweiten gesetzlichen Feiertage einer Kalenderwoche als
+// ganztägige Sondertage zurück. Landesfeiertage müssen von Hand eingetragen werden.
+func publicHolidays(year int, week int) []SpecialDay {
+	holidays := []SpecialDay{}
+	for day := 1; day <= 7; day++ {
+		date := isoweek.Day(year, week, day)
+		if name, ok := publicHolidayName(date); ok {
+			label := name
+			holidays = append(holidays, SpecialDay{Day: day, Type: specialDayHoliday, Label: &label, Meals: scopeList{}, Groups: scopeList{}})
+		}
+	}
+	return holidays
+}
+
+// publicHolidayName gibt den Namen eines bundesweiten gesetzlichen Feiertags zurück
+func publicHolidayName(date time.Time) (string, bool) {
+	switch date.Format("01-02") {
+	case "01-01":
+		return "Neujahr", true
+	case "05-01":
+		return "Tag der Arbeit", true
+	case "10-03":
+		return "Tag der Deutschen Einheit", true
+	case "12-25":
+		return "1. Weihnachtstag", true
+	case "12-26":
+		return "2. Weihnachtstag", true
+	}
+
+	easter := easterSunday(date.Year())
+	switch int(date.Sub(easter).Hours() / 24) {
+	case -2:
+		return "Karfreitag", true
+	case 1:
+		return "Ostermontag", true
+	case 39:
+		return "Christi Himmelfahrt", true
+	case 50:
+		return "Pfingstmontag", true
+	}
+	return "", false
+}
+
+// easterSunday berechnet den Ostersonntag nach der Gaußschen Osterformel (gregorianisch)
+func easterSunday(year int) time.Time {
+	a := year % 19
+	b := year / 100
+	c := year % 100
+	d := b / 4
+	e := b % 4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i := c / 4
+	k := c % 4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// REIHENFOLGE
+
+// MovePlanEntry verschiebt einen Planeintrag an einen anderen Tag, eine andere
+// Mahlzeit und/oder Position; die übrigen Einträge rücken lückenlos nach
+func (a *App) MovePlanEntry(id int, day int, meal string, slot int) (*WeekPlan, error) {
+	if err := validateOperatingDay(day); err != nil {
+		return nil, err
+	}
+	if err := validatePlanMeal(meal); err != nil {
+		return nil, err
+	}
+
+	before, err := a.getPlanEntry(db, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := ensurePlanEditable(before.WeekPlanID); err != nil {
+		return nil, err
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Eintrag vorübergehend aus der Reihenfolge nehmen (-1 ist sonst nie belegt)
+	if _, err := tx.Exec("UPDATE plan_entries SET slot = -1 WHERE id = ?", id); err != nil {
+		return nil, fmt.Errorf("failed to detach plan entry: %w", err)
+	}
+	if err := renumberSlots(tx, "week_plan_id = ? AND day = ? AND meal = ?", before.WeekPlanID, before.Day, before.Meal); err != nil {
+		return nil, err
+	}
+
+	target, err := openSlot(tx, before.WeekPlanID, day, meal, slot)
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.Exec("UPDATE plan_entries SET day = ?, meal = ?, slot = ? WHERE id = ?", day, meal, target, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to move plan entry: %w", err)
+	}
+
+	after, err := a.getPlanEntry(tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.audit(tx, auditActionUpdate, auditEntityPlanEntry, id, &after.WeekPlanID, before, after); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	a.recordPlanChange(planChange{weekPlanID: after.WeekPlanID, entries: []entryChange{{id: id, before: before, after: after}}})
+
+	return a.getWeekPlanByID(db, after.WeekPlanID)
+}
+
+// SwapDays tauscht alle Planeinträge zweier Tage; Sondertage bleiben am Kalendertag
+func (a *App) SwapDays(weekPlanID int, dayA int, dayB int) (*WeekPlan, error) {
+	if err := validateOperatingDay(dayA); err != nil {
+		return nil, err
+	}
+	if err := validateOperatingDay(dayB); err != nil {
+		return nil, err
+	}
+	if err := ensurePlanEditable(weekPlanID); err != nil {
+		return nil, err
+	}
+	if dayA == dayB {
+		return a.getWeekPlanByID(db, weekPlanID)
+	}
+
+	before, err := a.loadPlanEntries(db, weekPlanID)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Über negative Tage tauschen, damit der Unique-Index nicht zwischendurch greift
+	_, err = tx.Exec(`
+		UPDATE plan_entries SET day = CASE WHEN day = ? THEN -? ELSE -? END
+		WHERE week_plan_id = ? AND day IN (?, ?)
+	`, dayA, dayB, dayA, weekPlanID, dayA, dayB)
+	if err != nil {
+		return nil, fmt.Errorf("failed to swap days: %w", err)
+	}
+	_, err = tx.Exec("UPDATE plan_entries SET day = -day WHERE week_plan_id = ? AND day < 0", weekPlanID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to swap days: %w", err)
+	}
+
+	change := planChange{weekPlanID: weekPlanID}
+	for i := range before {
+		entry := &before[i]
+		if entry.Day != dayA && entry.Day != dayB {
+			continue
+		}
+		after, err := a.getPlanEntry(tx, entry.ID)
+		if err != nil {
+			return nil, err
+		}
+		if err := a.audit(tx, auditActionUpdate, auditEntityPlanEntry, entry.ID, &weekPlanID, entry, after); err != nil {
+			return nil, err
+		}
+		change.entries = append(change.entries, entryChange{id: entry.ID, before: entry, after: after})
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	if len(change.entries) > 0 {
+		a.recordPlanChange(change)
+	}
+
+	return a.getWeekPlanByID(db, weekPlanID)
+}
+
+// HILFSFUNKTIONEN
+
+// validatePlanDay prüft einen Wochentag (1=Mo … 7=So)
+func validatePlanDay(day int) error {
+	if day < 1 || day > 7 {
+		return fmt.Errorf("invalid day %d, expected 1-7", day)
+	}
+	return nil
+}
+
+// validatePlanMeal prüft, ob meal eine der Mahlzeiten aus planMeals ist
+func validatePlanMeal(meal string) error {
+	for _, m := range planMeals {
+		if m == meal {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid meal %q", meal)
+}
+
+// renumberSlots nummeriert die Slots je Plan/Tag/Mahlzeit lückenlos ab 0 durch.
+// Negative Slots (vorübergehend herausgenommene Einträge) bleiben unberührt.
+// Der Umweg über negative Werte verhindert Konflikte mit dem Unique-Index.
+func renumberSlots(ex sqlx.Execer, where string, args ...interface{}) error {
+	_, err := ex.Exec(`
+		UPDATE plan_entries SET slot = -2 - r.rn
+		FROM (
+			SELECT id, ROW_NUMBER() OVER (PARTITION BY week_plan_id, day, meal ORDER BY slot, id) - 1 AS rn
+			FROM plan_entries
+			WHERE slot >= 0 AND `+where+`
+		) r
+		WHERE r.id = plan_entries.id
+	`, args...)
+	if err != nil {
+		return fmt.Errorf("failed to renumber slots: %w", err)
+	}
+
+	if _, err := ex.Exec("UPDATE plan_entries SET slot = -2 - slot WHERE slot <= -2"); err != nil {
+		return fmt.Errorf("failed to renumber slots: %w", err)
+	}
+	return nil
+}
+
+// openSlot schafft an Position slot Platz für einen Eintrag, indem nachfolgende
+// Einträge um eins nach hinten rücken. Positionen hinter dem Ende werden auf das
+// Ende begrenzt; zurückgegeben wird die tatsächlich freie Position.
+func openSlot(tx *sqlx.Tx, weekPlanID int, day int, meal string, slot int) (int, error) {
+	var count int
+	err := tx.Get(&count, "SELECT COUNT(*) FROM plan_entries WHERE week_plan_id = ? AND day = ? AND meal = ? AND slot >= 0", weekPlanID, day, meal)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count plan entries: %w", err)
+	}
+	if slot < 0 || slot > count {
+		slot = count
+	}
+	if slot == count {
+		return slot, nil
+	}
+
+	_, err = tx.Exec(`
+		UPDATE plan_entries SET slot = -2 - (slot + 1)
+		WHERE week_plan_id = ? AND day = ? AND meal = ? AND slot >= ?
+	`, weekPlanID, day, meal, slot)
+	if err != nil {
+		return 0, fmt.Errorf("failed to shift plan entries: %w", err)
+	}
+	if _, err := tx.Exec("UPDATE plan_entries SET slot = -2 - slot WHERE slot <= -2"); err != nil {
+		return 0, fmt.Errorf("failed to shift plan entries: %w", err)
+	}
+	return slot, nil
+}
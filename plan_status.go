@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Status eines Wochenplans
+const (
+	planStatusDraft     = "draft"
+	planStatusReview    = "review"
+	planStatusApproved  = "approved"
+	planStatusPublished = "published"
+	planStatusServed    = "served" // ausgegeben, Verbrauch ist im Lager gebucht
+)
+
+// planStatusTransitions listet die erlaubten Statuswechsel; Zurücksetzen
+// freigegebener Pläne geht nur über ReopenWeekPlan
+var planStatusTransitions = map[string][]string{
+	planStatusDraft:     {planStatusReview},
+	planStatusReview:    {planStatusDraft, planStatusApproved},
+	planStatusApproved:  {planStatusPublished},
+	planStatusPublished: {planStatusServed},
+}
+
+// FREIGABE
+
+// SetWeekPlanStatus setzt den Status eines Wochenplans (Entwurf → Prüfung → freigegeben → veröffentlicht → ausgegeben).
+// Bei der Freigabe werden Bearbeiter und Zeitpunkt festgehalten; bei der Ausgabe wird der Verbrauch
+// der Lagerartikel gebucht.
+func (a *App) SetWeekPlanStatus(weekPlanID int, status string) (*WeekPlan, error) {
+	before, err := a.getWeekPlanByID(db, weekPlanID)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed := false
+	for _, next := range planStatusTransitions[before.Status] {
+		if next == status {
+			allowed = true
+		}
+	}
+	if !allowed {
+		return nil, fmt.Errorf("status change from %s to %s is not allowed", before.Status, status)
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if status == planStatusServed {
+		if err := a.bookPlanConsumption(tx, before); err != nil {
+			return nil, err
+		}
+	}
+	if status == planStatusApproved {
+		_, err = tx.Exec("UPDATE week_plans SET status = ?, approved_by = ?, approved_at = ? WHERE id = ?",
+			status, a.currentActor(), time.Now().Format(auditTimeLayout), weekPlanID)
+	} else {
+		_, err = tx.Exec("UPDATE week_plans SET status = ? WHERE id = ?", status, weekPlanID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update week plan status: %w", err)
+	}
+
+	after, err := a.getWeekPlanByID(tx, weekPlanID)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.audit(tx, auditActionStatus, auditEntityWeekPlan, weekPlanID, &weekPlanID, planStatusSnapshot(before, ""), planStatusSnapshot(after, "")); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return after, nil
+}
+
+// ReopenWeekPlan setzt einen freigegebenen, veröffentlichten oder ausgegebenen Plan zur Bearbeitung
+// zurück auf Entwurf; Freigabe und Begründung landen im Änderungsprotokoll. Gebuchter
+// Verbrauch eines ausgegebenen Plans wird gegengebucht.
+func (a *App) ReopenWeekPlan(weekPlanID int, reason string) (*WeekPlan, error) {
+	before, err := a.getWeekPlanByID(db, weekPlanID)
+	if err != nil {
+		return nil, err
+	}
+	if before.Status != planStatusApproved && before.Status != planStatusPublished && before.Status != planStatusServed {
+		return nil, fmt.Errorf("only approved, published or served week plans can be reopened")
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if before.Status == planStatusServed {
+		if err := a.cancelPlanConsumption(tx, before); err != nil {
+			return nil, err
+		}
+	}
+	_, err = tx.Exec("UPDATE week_plans SET status = ?, approved_by = NULL, approved_at = NULL WHERE id = ?", planStatusDraft, weekPlanID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reopen week plan: %w", err)
+	}
+
+	after, err := a.getWeekPlanByID(tx, weekPlanID)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.audit(tx, auditActionReopen, auditEntityWeekPlan, weekPlanID, &weekPlanID, planStatusSnapshot(before, ""), planStatusSnapshot(after, strings.TrimSpace(reason))); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return after, nil
+}
+
+// HILFSFUNKTIONEN
+
+// planStatusSnapshot ist der protokollierte Freigabestand eines Plans
+func planStatusSnapshot(plan *WeekPlan, reason string) map[string]interface{} {
+	snapshot := map[string]interface{}{
+		"status":      plan.Status,
+		"approved_by": plan.ApprovedBy,
+		"approved_at": plan.ApprovedAt,
+	}
+	if reason != "" {
+		snapshot["reason"] = reason
+	}
+	return snapshot
+}
+
+// ensurePlanEditable verweigert Änderungen an freigegebenen, veröffentlichten, ausgegebenen und archivierten Plänen
+func ensurePlanEditable(weekPlanID int) error {
+	var plan struct {
+		Status   string `db:"status"`
+		Archived bool   `db:"archived"`
+	}
+	if err := db.Get(&plan, "SELECT status, archived FROM week_plans WHERE id = ?", weekPlanID); err != nil {
+		return fmt.Errorf("failed to get week plan status: %w", err)
+	}
+	if plan.Archived {
+		return fmt.Errorf("week plan is archived and read-only; unarchive it first")
+	}
+	if plan.Status == planStatusApproved || plan.Status == planStatusPublished || plan.Status == planStatusServed {
+		return fmt.Errorf("week plan is %s and locked; reopen it first", plan.Status)
+	}
+	return nil
+}
@@ -0,0 +1,319 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ARCHIVIEREN & ZUSAMMENFÜHREN
+
+// ArchiveProduct blendet ein Produkt in Auswahllisten aus; alte Pläne zeigen es weiterhin
+func (a *App) ArchiveProduct(id int) (*Product, error) {
+	return a.setProductArchived(id, true)
+}
+
+// RestoreProduct macht ein archiviertes Produkt wieder auswählbar
+func (a *App) RestoreProduct(id int) (*Product, error) {
+	return a.setProductArchived(id, false)
+}
+
+// GetArchivedProducts gibt alle archivierten Produkte zurück
+func (a *App) GetArchivedProducts() ([]Product, error) {
+	products, err := a.FilterProducts(ProductFilter{IncludeArchived: true})
+	if err != nil {
+		return nil, err
+	}
+
+	archived := []Product{}
+	for _, p := range products {
+		if p.Archived {
+			archived = append(archived, p)
+		}
+	}
+	return archived, nil
+}
+
+// GetProductUsage listet die Wochenpläne sowie Vorlagen- und Rotationswochen, in denen
+// ein Produkt vorkommt
+func (a *App) GetProductUsage(id int) (*ProductUsageReport, error) {
+	usage := &ProductUsageReport{Plans: []ProductUsage{}, Templates: []TemplateUsage{}}
+	err := db.Select(&usage.Plans, `
+		SELECT wp.id AS week_plan_id, wp.year, wp.week, COUNT(*) AS entries
+		FROM plan_entries pe
+		JOIN week_plans wp ON wp.id = pe.week_plan_id
+		WHERE pe.product_id = ?
+		GROUP BY wp.id, wp.year, wp.week
+		ORDER BY wp.year DESC, wp.week DESC
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product usage: %w", err)
+	}
+
+	err = db.Select(&usage.Templates, `
+		SELECT tw.id AS template_week_id, tw.name, r.name AS rotation_name, COUNT(*) AS entries
+		FROM template_entries te
+		JOIN template_weeks tw ON tw.id = te.template_week_id
+		LEFT JOIN rotations r ON r.id = tw.rotation_id
+		WHERE te.product_id = ?
+		GROUP BY tw.id, tw.name, r.name
+		ORDER BY r.name, tw.position, tw.name
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product template usage: %w", err)
+	}
+	return usage, nil
+}
+
+// MergeProducts führt ein doppelt angelegtes Produkt (source) mit target zusammen:
+// Planeinträge werden auf target umgeschrieben, dadurch entstandene Dubletten
+// im selben Tag/Mahlzeit/Gruppe entfernt und source anschließend gelöscht.
+// Kategorien, Schlagworte und Lebensmittelgruppen von source werden übernommen,
+// Allergene und Zusatzstoffe bleiben die von target. Preise, Lieferantenzuordnung und
+// Lagerartikel wandern zu target, soweit target dort nichts Eigenes hat; Lagerbewegungen
+// von source werden dem Lagerartikel von target zugeschlagen. Kommt source in einem freigegebenen,
+// veröffentlichten, ausgegebenen oder archivierten Plan vor, wird nicht zusammengeführt.
+func (a *App) MergeProducts(sourceID int, targetID int) (*MergeResult, error) {
+	if sourceID == targetID {
+		return nil, fmt.Errorf("cannot merge a product with itself")
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Beide Produkte müssen existieren
+	var found int
+	err = tx.Get(&found, "SELECT COUNT(*) FROM products WHERE id IN (?, ?)", sourceID, targetID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check products: %w", err)
+	}
+	if found != 2 {
+		return nil, fmt.Errorf("source or target product not found")
+	}
+
+	// Gesperrte Pläne dürfen sich durch das Zusammenführen nicht ändern: weder Pläne mit
+	// source noch Pläne, in denen target mit einem umgeschriebenen Eintrag zusammenfällt
+	var locked []WeekRef
+	err = tx.Select(&locked, `
+		SELECT DISTINCT wp.year, wp.week
+		FROM plan_entries pe
+		JOIN week_plans wp ON wp.id = pe.week_plan_id
+		WHERE (wp.archived = 1 OR wp.status IN (?, ?, ?))
+		AND (pe.product_id = ? OR (pe.product_id = ? AND EXISTS (
+			SELECT 1 FROM plan_entries src
+			WHERE src.product_id = ?
+			AND src.week_plan_id = pe.week_plan_id
+			AND src.day = pe.day
+			AND src.meal = pe.meal
+			AND COALESCE(src.group_label, '') = COALESCE(pe.group_label, '')
+		)))
+		ORDER BY wp.year, wp.week
+	`, planStatusApproved, planStatusPublished, planStatusServed, sourceID, targetID, sourceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check locked week plans: %w", err)
+	}
+	if len(locked) > 0 {
+		weeks := make([]string, 0, len(locked))
+		for _, w := range locked {
+			weeks = append(weeks, fmt.Sprintf("%d/%d", w.Week, w.Year))
+		}
+		return nil, fmt.Errorf("source product is used in locked week plans (%s); reopen them first", strings.Join(weeks, ", "))
+	}
+
+	source, err := getProduct(tx, sourceID)
+	if err != nil {
+		return nil, err
+	}
+	target, err := getProduct(tx, targetID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &MergeResult{}
+
+	// Nur die umgeschriebenen Einträge kommen als Dubletten in Frage
+	var movedIDs []int
+	if err := tx.Select(&movedIDs, "SELECT id FROM plan_entries WHERE product_id = ?", sourceID); err != nil {
+		return nil, fmt.Errorf("failed to get plan entries: %w", err)
+	}
+
+	// Die Revisionen von source entfallen mit dem Produkt; umgeschriebene Einträge
+	// verweisen auf die aktuelle Revision von target
+	res, err := tx.Exec(`
+		UPDATE plan_entries SET product_id = ?, product_revision_id = `+currentRevisionSQL+`
+		WHERE product_id = ?
+	`, targetID, targetID, sourceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rewrite plan entries: %w", err)
+	}
+	moved, _ := res.RowsAffected()
+	result.EntriesMoved = int(moved)
+
+	// Dubletten entfernen: pro Plan/Tag/Mahlzeit/Gruppe bleibt ein schon vorhandener
+	// Eintrag von target bzw. der erste umgeschriebene; gelöscht werden nur umgeschriebene
+	if len(movedIDs) > 0 {
+		var plans []int
+		query, args, err := sqlx.In("SELECT DISTINCT week_plan_id FROM plan_entries WHERE id IN (?)", movedIDs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build query: %w", err)
+		}
+		if err := tx.Select(&plans, tx.Rebind(query), args...); err != nil {
+			return nil, fmt.Errorf("failed to get affected week plans: %w", err)
+		}
+
+		query, args, err = sqlx.In(`
+			DELETE FROM plan_entries
+			WHERE id IN (?)
+			AND EXISTS (
+				SELECT 1 FROM plan_entries other
+				WHERE other.product_id = plan_entries.product_id
+				AND other.week_plan_id = plan_entries.week_plan_id
+				AND other.day = plan_entries.day
+				AND other.meal = plan_entries.meal
+				AND COALESCE(other.group_label, '') = COALESCE(plan_entries.group_label, '')
+				AND other.id != plan_entries.id
+				AND (other.id NOT IN (?) OR other.slot < plan_entries.slot OR (other.slot = plan_entries.slot AND other.id < plan_entries.id))
+			)
+		`, movedIDs, movedIDs)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build query: %w", err)
+		}
+		res, err = tx.Exec(tx.Rebind(query), args...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to remove duplicate entries: %w", err)
+		}
+		removed, _ := res.RowsAffected()
+		result.DuplicatesRemoved = int(removed)
+		if removed > 0 {
+			query, args, err := sqlx.In("week_plan_id IN (?)", plans)
+			if err != nil {
+				return nil, fmt.Errorf("failed to build query: %w", err)
+			}
+			if err := renumberSlots(tx, query, args...); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Vorlagen und Rotationen verweisen künftig auf target
+	if _, err := tx.Exec("UPDATE template_entries SET product_id = ? WHERE product_id = ?", targetID, sourceID); err != nil {
+		return nil, fmt.Errorf("failed to rewrite template entries: %w", err)
+	}
+
+	// Zuordnungen übernehmen
+	links := []string{
+		"INSERT OR IGNORE INTO product_categories (product_id, category_id) SELECT ?, category_id FROM product_categories WHERE product_id = ?",
+		"INSERT OR IGNORE INTO product_tags (product_id, tag_id) SELECT ?, tag_id FROM product_tags WHERE product_id = ?",
+		"INSERT OR IGNORE INTO product_food_groups (product_id, food_group_id) SELECT ?, food_group_id FROM product_food_groups WHERE product_id = ?",
+	}
+	for _, query := range links {
+		if _, err := tx.Exec(query, targetID, sourceID); err != nil {
+			return nil, fmt.Errorf("failed to copy product links: %w", err)
+		}
+	}
+
+	// Preise: bei gleichem Gültigkeitsdatum gilt der Preis von target
+	if _, err := tx.Exec("UPDATE OR IGNORE product_prices SET product_id = ? WHERE product_id = ?", targetID, sourceID); err != nil {
+		return nil, fmt.Errorf("failed to move product prices: %w", err)
+	}
+	// Lieferant: eine vorhandene Zuordnung von target bleibt bestehen
+	if _, err := tx.Exec("UPDATE OR IGNORE product_suppliers SET product_id = ? WHERE product_id = ?", targetID, sourceID); err != nil {
+		return nil, fmt.Errorf("failed to move product supplier: %w", err)
+	}
+	if err := mergeStockItems(tx, sourceID, targetID); err != nil {
+		return nil, err
+	}
+
+	for _, table := range []string{"product_prices", "product_suppliers"} {
+		if _, err := tx.Exec("DELETE FROM "+table+" WHERE product_id = ?", sourceID); err != nil {
+			return nil, fmt.Errorf("failed to delete source %s: %w", table, err)
+		}
+	}
+	if _, err := tx.Exec("DELETE FROM products WHERE id = ?", sourceID); err != nil {
+		return nil, fmt.Errorf("failed to delete source product: %w", err)
+	}
+
+	if err := a.audit(tx, auditActionDelete, auditEntityProduct, sourceID, nil, source, nil); err != nil {
+		return nil, err
+	}
+	result.Target, err = a.auditProductUpdate(tx, target)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return result, nil
+}
+
+// mergeStockItems überträgt den Lagerartikel von source auf target. Hat target bereits
+// einen Lagerartikel, wandern nur die Bewegungen; Mindestbestand und Lagerort bleiben.
+func mergeStockItems(tx *sqlx.Tx, sourceID int, targetID int) error {
+	var items []StockItem
+	if err := tx.Select(&items, "SELECT id, product_id FROM stock_items WHERE product_id IN (?, ?)", sourceID, targetID); err != nil {
+		return fmt.Errorf("failed to get stock items: %w", err)
+	}
+	var sourceItem, targetItem int
+	for _, item := range items {
+		if item.ProductID == sourceID {
+			sourceItem = item.ID
+		} else {
+			targetItem = item.ID
+		}
+	}
+	if sourceItem == 0 {
+		return nil
+	}
+
+	if targetItem == 0 {
+		if _, err := tx.Exec("UPDATE stock_items SET product_id = ? WHERE id = ?", targetID, sourceItem); err != nil {
+			return fmt.Errorf("failed to move stock item: %w", err)
+		}
+		return nil
+	}
+
+	if _, err := tx.Exec("UPDATE stock_movements SET stock_item_id = ? WHERE stock_item_id = ?", targetItem, sourceItem); err != nil {
+		return fmt.Errorf("failed to move stock movements: %w", err)
+	}
+	if _, err := tx.Exec("DELETE FROM stock_items WHERE id = ?", sourceItem); err != nil {
+		return fmt.Errorf("failed to delete source stock item: %w", err)
+	}
+	return nil
+}
+
+// setProductArchived setzt das Archiv-Flag eines Produkts
+func (a *App) setProductArchived(id int, archived bool) (*Product, error) {
+	before, err := a.GetProduct(id)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec("UPDATE products SET archived = ? WHERE id = ?", archived, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update product archive state: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, fmt.Errorf("product %d not found", id)
+	}
+
+	after, err := a.auditProductUpdate(tx, before)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return after, nil
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// currentRevisionSQL ermittelt die aktuelle Revision eines Produkts (ein Parameter: product_id)
+const currentRevisionSQL = `(SELECT pr.id FROM product_revisions pr WHERE pr.product_id = ? ORDER BY pr.revision DESC LIMIT 1)`
+
+// productRevisionRow bildet eine Zeile aus product_revisions ab (IDs kommagetrennt)
+type productRevisionRow struct {
+	ID        int       `db:"id"`
+	ProductID int       `db:"product_id"`
+	Revision  int       `db:"revision"`
+	Name      string    `db:"name"`
+	Multiline bool      `db:"multiline"`
+	Allergens string    `db:"allergens"`
+	Additives string    `db:"additives"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+func (r productRevisionRow) toRevision() ProductRevision {
+	return ProductRevision{
+		ID:          r.ID,
+		ProductID:   r.ProductID,
+		Revision:    r.Revision,
+		Name:        r.Name,
+		Multiline:   r.Multiline,
+		AllergenIDs: splitList(r.Allergens),
+		AdditiveIDs: splitList(r.Additives),
+		CreatedAt:   r.CreatedAt,
+	}
+}
+
+// REVISIONEN
+
+// GetProductRevisions gibt alle Revisionen eines Produkts zurück (neueste zuerst)
+func (a *App) GetProductRevisions(productID int) ([]ProductRevision, error) {
+	var rows []productRevisionRow
+	err := db.Select(&rows, `
+		SELECT id, product_id, revision, name, multiline, allergens, additives, created_at
+		FROM product_revisions
+		WHERE product_id = ?
+		ORDER BY revision DESC
+	`, productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product revisions: %w", err)
+	}
+
+	revisions := make([]ProductRevision, 0, len(rows))
+	for _, r := range rows {
+		revisions = append(revisions, r.toRevision())
+	}
+	return revisions, nil
+}
+
+// HILFSFUNKTIONEN
+
+// createProductRevision hält den aktuellen Stand eines Produkts als neue Revision fest.
+// Ist der Stand unverändert gegenüber der letzten Revision, wird diese zurückgegeben.
+func createProductRevision(tx *sqlx.Tx, productID int) (int, error) {
+	var product struct {
+		Name      string `db:"name"`
+		Multiline bool   `db:"multiline"`
+	}
+	if err := tx.Get(&product, "SELECT name, multiline FROM products WHERE id = ?", productID); err != nil {
+		return 0, fmt.Errorf("failed to load product %d for revision: %w", productID, err)
+	}
+
+	var allergenIDs, additiveIDs []string
+	if err := tx.Select(&allergenIDs, "SELECT allergen_id FROM product_allergens WHERE product_id = ?", productID); err != nil {
+		return 0, fmt.Errorf("failed to load allergens for revision: %w", err)
+	}
+	if err := tx.Select(&additiveIDs, "SELECT additive_id FROM product_additives WHERE product_id = ?", productID); err != nil {
+		return 0, fmt.Errorf("failed to load additives for revision: %w", err)
+	}
+	sort.Strings(allergenIDs)
+	sort.Strings(additiveIDs)
+	allergens := strings.Join(allergenIDs, ",")
+	additives := strings.Join(additiveIDs, ",")
+
+	var latest []productRevisionRow
+	err := tx.Select(&latest, `
+		SELECT id, product_id, revision, name, multiline, allergens, additives, created_at
+		FROM product_revisions
+		WHERE product_id = ?
+		ORDER BY revision DESC
+		LIMIT 1
+	`, productID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load latest revision: %w", err)
+	}
+
+	next := 1
+	if len(latest) > 0 {
+		l := latest[0]
+		if l.Name == product.Name && l.Multiline == product.Multiline && l.Allergens == allergens && l.Additives == additives {
+			return l.ID, nil
+		}
+		next = l.Revision + 1
+	}
+
+	result, err := tx.Exec(`
+		INSERT INTO product_revisions (product_id, revision, name, multiline, allergens, additives)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, productID, next, product.Name, product.Multiline, allergens, additives)
+	if err != nil {
+		return 0, fmt.Errorf("failed to insert product revision: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get revision ID: %w", err)
+	}
+	return int(id), nil
+}
+
+// ensureProductRevisions legt für Produkte ohne Revision die erste an und
+// verknüpft Planeinträge ohne Revision mit der aktuellen Revision ihres Produkts
+func ensureProductRevisions() error {
+	tx, err := db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var productIDs []int
+	err = tx.Select(&productIDs, `
+		SELECT id FROM products p
+		WHERE NOT EXISTS (SELECT 1 FROM product_revisions pr WHERE pr.product_id = p.id)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to find products without revision: %w", err)
+	}
+
+	for _, id := range productIDs {
+		if _, err := createProductRevision(tx, id); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec(`
+		UPDATE plan_entries
+		SET product_revision_id = (
+			SELECT pr.id FROM product_revisions pr
+			WHERE pr.product_id = plan_entries.product_id
+			ORDER BY pr.revision DESC LIMIT 1
+		)
+		WHERE product_id IS NOT NULL AND product_revision_id IS NULL
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to link plan entries to revisions: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// attachEntryProduct lädt das Produkt eines Planeintrags; historical ersetzt
+// Name und Kennzeichnung durch den Stand der verknüpften Revision
+func (a *App) attachEntryProduct(q sqlx.Queryer, entry *PlanEntry, historical bool) error {
+	if entry.ProductID == nil {
+		return nil
+	}
+
+	product, err := getProduct(q, *entry.ProductID)
+	if err != nil {
+		return err
+	}
+	entry.Product = product
+
+	if entry.ProductRevisionID == nil {
+		return nil
+	}
+
+	var row productRevisionRow
+	err = sqlx.Get(q, &row, `
+		SELECT id, product_id, revision, name, multiline, allergens, additives, created_at
+		FROM product_revisions
+		WHERE id = ?
+	`, *entry.ProductRevisionID)
+	if err != nil {
+		return fmt.Errorf("failed to load product revision: %w", err)
+	}
+
+	entry.ProductRevision = &row.Revision
+	entry.RevisionChanged = row.Revision != product.Revision
+
+	if historical {
+		return applyRevision(q, product, row.toRevision())
+	}
+	return nil
+}
+
+// applyRevision überschreibt Name und Kennzeichnung eines Produkts mit einer Revision
+func applyRevision(q sqlx.Queryer, product *Product, rev ProductRevision) error {
+	product.Name = rev.Name
+	product.Multiline = rev.Multiline
+	product.Allergens = []Allergen{}
+	product.Additives = []Additive{}
+
+	if len(rev.AllergenIDs) > 0 {
+		query, args, err := sqlx.In("SELECT id, name, category FROM allergens WHERE id IN (?) ORDER BY id", rev.AllergenIDs)
+		if err != nil {
+			return fmt.Errorf("failed to build allergen query: %w", err)
+		}
+		if err := sqlx.Select(q, &product.Allergens, query, args...); err != nil {
+			return fmt.Errorf("failed to load revision allergens: %w", err)
+		}
+	}
+
+	if len(rev.AdditiveIDs) > 0 {
+		query, args, err := sqlx.In("SELECT id, name FROM additives WHERE id IN (?) ORDER BY id", rev.AdditiveIDs)
+		if err != nil {
+			return fmt.Errorf("failed to build additive query: %w", err)
+		}
+		if err := sqlx.Select(q, &product.Additives, query, args...); err != nil {
+			return fmt.Errorf("failed to load revision additives: %w", err)
+		}
+	}
+
+	return nil
+}
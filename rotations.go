@@ -0,0 +1,390 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+
+	"speiseplan/isoweek"
+)
+
+// ROTATIONEN
+
+// GetRotations gibt alle Rotationen mit ihren Wochen zurück
+func (a *App) GetRotations() ([]Rotation, error) {
+	rotations := []Rotation{}
+	err := db.Select(&rotations, "SELECT id, name, anchor_year, anchor_week, created_at FROM rotations ORDER BY name")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rotations: %w", err)
+	}
+
+	for i := range rotations {
+		if err := a.loadRotationWeeks(&rotations[i]); err != nil {
+			return nil, err
+		}
+	}
+	return rotations, nil
+}
+
+// GetRotation gibt eine Rotation mit ihren Wochen zurück
+func (a *App) GetRotation(id int) (*Rotation, error) {
+	var rotation Rotation
+	err := db.Get(&rotation, "SELECT id, name, anchor_year, anchor_week, created_at FROM rotations WHERE id = ?", id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rotation: %w", err)
+	}
+
+	if err := a.loadRotationWeeks(&rotation); err != nil {
+		return nil, err
+	}
+	return &rotation, nil
+}
+
+// CreateRotation legt eine Rotation mit der angegebenen Anzahl leerer Wochen an.
+// Die erste Rotationswoche gilt in der Ankerwoche anchorYear/anchorWeek.
+func (a *App) CreateRotation(name string, weeks int, anchorYear int, anchorWeek int) (*Rotation, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("rotation name must not be empty")
+	}
+	if weeks < 1 {
+		return nil, fmt.Errorf("rotation needs at least one week")
+	}
+	if err := validateWeek(anchorYear, anchorWeek); err != nil {
+		return nil, err
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("INSERT INTO rotations (name, anchor_year, anchor_week) VALUES (?, ?, ?)", name, anchorYear, anchorWeek)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create rotation: %w", err)
+	}
+	rotationID, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rotation ID: %w", err)
+	}
+
+	for i := 0; i < weeks; i++ {
+		_, err := tx.Exec("INSERT INTO template_weeks (name, rotation_id, position) VALUES (?, ?, ?)",
+			fmt.Sprintf("Woche %d", i+1), rotationID, i)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create rotation week: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return a.GetRotation(int(rotationID))
+}
+
+// UpdateRotation ändert Name und Ankerwoche einer Rotation
+func (a *App) UpdateRotation(id int, name string, anchorYear int, anchorWeek int) (*Rotation, error) {
+	if err := validateWeek(anchorYear, anchorWeek); err != nil {
+		return nil, err
+	}
+
+	_, err := db.Exec("UPDATE rotations SET name = ?, anchor_year = ?, anchor_week = ? WHERE id = ?",
+		strings.TrimSpace(name), anchorYear, anchorWeek, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update rotation: %w", err)
+	}
+	return a.GetRotation(id)
+}
+
+// DeleteRotation löscht eine Rotation mit ihren Wochen; erzeugte Wochenpläne bleiben erhalten
+func (a *App) DeleteRotation(id int) error {
+	_, err := db.Exec("DELETE FROM rotations WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete rotation: %w", err)
+	}
+	return nil
+}
+
+// AddRotationWeek hängt eine leere Woche an eine Rotation an
+func (a *App) AddRotationWeek(rotationID int) (*Rotation, error) {
+	_, err := db.Exec(`
+		INSERT INTO template_weeks (name, rotation_id, position)
+		SELECT 'Woche ' || (COUNT(*) + 1), ?, COUNT(*) FROM template_weeks WHERE rotation_id = ?
+	`, rotationID, rotationID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add rotation week: %w", err)
+	}
+	return a.GetRotation(rotationID)
+}
+
+// RemoveRotationWeek entfernt eine Woche aus ihrer Rotation; die folgenden Wochen rücken auf
+func (a *App) RemoveRotationWeek(templateWeekID int) (*Rotation, error) {
+	tw, err := a.getTemplateWeek(templateWeekID)
+	if err != nil {
+		return nil, err
+	}
+	if tw.RotationID == nil {
+		return nil, fmt.Errorf("template week %d is not part of a rotation", templateWeekID)
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM template_weeks WHERE id = ?", templateWeekID); err != nil {
+		return nil, fmt.Errorf("failed to remove rotation week: %w", err)
+	}
+	_, err = tx.Exec("UPDATE template_weeks SET position = position - 1 WHERE rotation_id = ? AND position > ?", *tw.RotationID, tw.Position)
+	if err != nil {
+		return nil, fmt.Errorf("failed to renumber rotation weeks: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return a.GetRotation(*tw.RotationID)
+}
+
+// ApplyRotation erzeugt Wochenpläne für alle Kalenderwochen zwischen from und to
+// (YYYY-MM-DD) aus der Rotation. Tage mit Sondertag (Feiertag, Schließtag) bleiben
+// leer; neu angelegte Wochen erhalten dafür die bundesweiten Feiertage. Bestehende Pläne werden neu befüllt; mit onlyUntouched nur dann, wenn sie
+// leer sind oder seit der letzten Erzeugung nicht von Hand geändert wurden.
+func (a *App) ApplyRotation(rotationID int, from string, to string, onlyUntouched bool) (*RotationResult, error) {
+	rotation, err := a.GetRotation(rotationID)
+	if err != nil {
+		return nil, err
+	}
+	if len(rotation.Weeks) == 0 {
+		return nil, fmt.Errorf("rotation %q has no weeks", rotation.Name)
+	}
+
+	weeks, err := weeksInRange(from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	type pending struct {
+		ref    WeekRef
+		before *WeekPlan
+	}
+	var work []pending
+	result := &RotationResult{Generated: []WeekRef{}, Skipped: []WeekRef{}}
+
+	for _, ref := range weeks {
+		plan, err := a.GetWeekPlan(ref.Year, ref.Week)
+		if err != nil && !isNotFound(err) {
+			return nil, err
+		}
+		// Freigegebene Pläne bleiben unangetastet
+		if plan != nil && ensurePlanEditable(plan.ID) != nil {
+			result.Skipped = append(result.Skipped, ref)
+			continue
+		}
+		if plan != nil && onlyUntouched {
+			untouched, err := planUntouched(plan)
+			if err != nil {
+				return nil, err
+			}
+			if !untouched {
+				result.Skipped = append(result.Skipped, ref)
+				continue
+			}
+		}
+		work = append(work, pending{ref: ref, before: plan})
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var changes []planChange
+	for _, w := range work {
+		weekPlanID := 0
+		var specials []SpecialDay
+		var change planChange
+		if w.before != nil {
+			weekPlanID = w.before.ID
+			specials = w.before.SpecialDays
+			change.weekPlanID = weekPlanID
+			for i := range w.before.Entries {
+				change.entries = append(change.entries, entryChange{id: w.before.Entries[i].ID, before: &w.before.Entries[i]})
+			}
+			if _, err := tx.Exec("DELETE FROM plan_entries WHERE week_plan_id = ?", weekPlanID); err != nil {
+				return nil, fmt.Errorf("failed to clear week plan: %w", err)
+			}
+		} else {
+			res, err := tx.Exec("INSERT INTO week_plans (year, week) VALUES (?, ?)", w.ref.Year, w.ref.Week)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create week plan: %w", err)
+			}
+			id, err := res.LastInsertId()
+			if err != nil {
+				return nil, fmt.Errorf("failed to get week plan ID: %w", err)
+			}
+			weekPlanID = int(id)
+			change.weekPlanID = weekPlanID
+
+			// Neue Wochen bekommen die gesetzlichen Feiertage, damit diese Tage leer bleiben
+			for _, holiday := range publicHolidays(w.ref.Year, w.ref.Week) {
+				res, err := tx.Exec(`
+					INSERT INTO special_days (week_plan_id, day, type, label, meals, groups)
+					VALUES (?, ?, ?, ?, ?, ?)
+				`, weekPlanID, holiday.Day, holiday.Type, holiday.Label, holiday.Meals, holiday.Groups)
+				if err != nil {
+					return nil, fmt.Errorf("failed to create holiday: %w", err)
+				}
+				id, err := res.LastInsertId()
+				if err != nil {
+					return nil, fmt.Errorf("failed to get special day ID: %w", err)
+				}
+				holiday.ID = int(id)
+				holiday.WeekPlanID = weekPlanID
+				specials = append(specials, holiday)
+				change.specials = append(change.specials, newSpecialChange(nil, &holiday))
+			}
+		}
+
+		tw := rotation.Weeks[rotationIndex(rotation, w.ref)]
+		ids, err := insertTemplateEntries(tx, tw.Entries, weekPlanID, specials, map[string]int{})
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			entry, err := a.getPlanEntry(tx, id)
+			if err != nil {
+				return nil, err
+			}
+			change.entries = append(change.entries, entryChange{id: id, after: entry})
+		}
+
+		hash, err := planFingerprint(tx, weekPlanID)
+		if err != nil {
+			return nil, err
+		}
+		_, err = tx.Exec("UPDATE week_plans SET rotation_id = ?, generated_hash = ? WHERE id = ?", rotationID, hash, weekPlanID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to mark generated week plan: %w", err)
+		}
+		result.Generated = append(result.Generated, w.ref)
+
+		plan, err := a.getWeekPlanByID(tx, weekPlanID)
+		if err != nil {
+			return nil, err
+		}
+		if err := a.audit(tx, auditActionGenerate, auditEntityWeekPlan, plan.ID, &plan.ID, w.before, plan); err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	// Jede erzeugte Woche lässt sich einzeln rückgängig machen
+	for _, change := range changes {
+		if len(change.entries) > 0 || len(change.specials) > 0 {
+			a.recordPlanChange(change)
+		}
+	}
+	return result, nil
+}
+
+// HILFSFUNKTIONEN
+
+// loadRotationWeeks lädt die Wochen einer Rotation in Reihenfolge
+func (a *App) loadRotationWeeks(rotation *Rotation) error {
+	var ids []int
+	err := db.Select(&ids, "SELECT id FROM template_weeks WHERE rotation_id = ? ORDER BY position", rotation.ID)
+	if err != nil {
+		return fmt.Errorf("failed to load rotation weeks: %w", err)
+	}
+
+	rotation.Weeks = []TemplateWeek{}
+	for _, id := range ids {
+		tw, err := a.getTemplateWeek(id)
+		if err != nil {
+			return err
+		}
+		rotation.Weeks = append(rotation.Weeks, *tw)
+	}
+	return nil
+}
+
+// rotationIndex gibt die Rotationswoche zurück, die in einer Kalenderwoche gilt
+func rotationIndex(rotation *Rotation, ref WeekRef) int {
+	weeks := isoweek.WeeksBetween(rotation.AnchorYear, rotation.AnchorWeek, ref.Year, ref.Week)
+
+	n := len(rotation.Weeks)
+	return ((weeks % n) + n) % n
+}
+
+// weeksInRange gibt alle Kalenderwochen zurück, die den Zeitraum from–to (YYYY-MM-DD) berühren
+func weeksInRange(from, to string) ([]WeekRef, error) {
+	start, err := isoweek.ParseDate(from)
+	if err != nil {
+		return nil, err
+	}
+	end, err := isoweek.ParseDate(to)
+	if err != nil {
+		return nil, err
+	}
+	if end.Before(start) {
+		return nil, fmt.Errorf("end date is before start date")
+	}
+
+	var weeks []WeekRef
+	for d := start.AddDate(0, 0, 1-isoweek.Weekday(start)); !d.After(end); d = d.AddDate(0, 0, 7) {
+		year, week := d.ISOWeek()
+		weeks = append(weeks, WeekRef{Year: year, Week: week})
+	}
+	return weeks, nil
+}
+
+// planUntouched prüft, ob ein Wochenplan leer ist oder seit der Erzeugung unverändert blieb
+func planUntouched(plan *WeekPlan) (bool, error) {
+	if len(plan.Entries) == 0 {
+		return true, nil
+	}
+
+	var stored *string
+	if err := db.Get(&stored, "SELECT generated_hash FROM week_plans WHERE id = ?", plan.ID); err != nil {
+		return false, fmt.Errorf("failed to get generation state: %w", err)
+	}
+	if stored == nil {
+		return false, nil
+	}
+
+	hash, err := planFingerprint(db, plan.ID)
+	if err != nil {
+		return false, err
+	}
+	return hash == *stored, nil
+}
+
+// planFingerprint bildet eine Prüfsumme über die Einträge eines Wochenplans
+func planFingerprint(q sqlx.Queryer, weekPlanID int) (string, error) {
+	var rows []string
+	err := sqlx.Select(q, &rows, `
+		SELECT day || '|' || meal || '|' || slot || '|' || COALESCE(product_id, '') || '|' ||
+			COALESCE(custom_text, '') || '|' || COALESCE(group_label, '')
+		FROM plan_entries
+		WHERE week_plan_id = ?
+		ORDER BY day, meal, slot
+	`, weekPlanID)
+	if err != nil {
+		return "", fmt.Errorf("failed to fingerprint week plan: %w", err)
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join(rows, "\n")))
+	return hex.EncodeToString(sum[:]), nil
+}
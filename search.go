@@ -0,0 +1,302 @@
+package main
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"sort"
+	"strings"
+
+	"modernc.org/sqlite"
+)
+
+func init() {
+	// normalize_de wird von den FTS-Triggern verwendet und muss vor dem
+	// Öffnen der Datenbank registriert sein
+	sqlite.MustRegisterDeterministicScalarFunction("normalize_de", 1,
+		func(ctx *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+			s, ok := args[0].(string)
+			if !ok {
+				return "", nil
+			}
+			return normalizeGerman(s), nil
+		})
+}
+
+// germanAbbreviations löst gängige Abkürzungen aus Produktnamen auf
+var germanAbbreviations = map[string]string{
+	"m":   "mit",
+	"o":   "oder",
+	"od":  "oder",
+	"u":   "und",
+	"bag": "baguette",
+	"br":  "brot",
+	"vk":  "vollkorn",
+	"gem": "gemuese",
+}
+
+// searchStopWords werden bei der Suche ignoriert
+var searchStopWords = map[string]bool{
+	"mit": true, "und": true, "oder": true,
+}
+
+// germanReplacer vereinheitlicht Umlaute, ß und Trennzeichen
+var germanReplacer = strings.NewReplacer(
+	"ä", "ae", "ö", "oe", "ü", "ue", "ß", "ss",
+	"&", " und ", "/", " ", "-", " ", "(", " ", ")", " ", ",", " ", "+", " ",
+)
+
+// normalizeGerman bringt einen Produktnamen oder Suchbegriff in eine
+// vergleichbare Form: Kleinschreibung, Umlaute ausgeschrieben, Abkürzungen aufgelöst
+func normalizeGerman(s string) string {
+	s = germanReplacer.Replace(strings.ToLower(s))
+
+	var words []string
+	for _, word := range strings.Fields(s) {
+		word = strings.Trim(word, ".;:!?\"'")
+		if word == "" {
+			continue
+		}
+		if full, ok := germanAbbreviations[word]; ok {
+			word = full
+		}
+		words = append(words, word)
+	}
+	return strings.Join(words, " ")
+}
+
+// SearchProducts sucht Produkte per Volltext mit Tippfehlertoleranz
+func (a *App) SearchProducts(query string, limit int) ([]Product, error) {
+	return a.SearchProductsFiltered(query, limit, ProductFilter{})
+}
+
+// SearchProductsFiltered sucht Produkte per Volltext und schränkt das Ergebnis
+// zusätzlich über den Filter ein (z.B. allergenfrei, vegetarisch)
+func (a *App) SearchProductsFiltered(query string, limit int, filter ProductFilter) ([]Product, error) {
+	if limit <= 0 {
+		limit = 20
+	}
+
+	var tokens []string
+	for _, token := range strings.Fields(normalizeGerman(query)) {
+		if !searchStopWords[token] {
+			tokens = append(tokens, token)
+		}
+	}
+
+	// Ohne Suchbegriff: gefilterte Liste
+	if len(tokens) == 0 {
+		products, err := a.FilterProducts(filter)
+		if err != nil {
+			return nil, err
+		}
+		if len(products) > limit {
+			products = products[:limit]
+		}
+		return products, nil
+	}
+
+	where, args := productFilterSQL(filter)
+
+	// 1. Präfixsuche über den FTS-Index, sortiert nach bm25
+	var match []string
+	for _, token := range tokens {
+		match = append(match, `"`+strings.ReplaceAll(token, `"`, `""`)+`"*`)
+	}
+	var ids []int
+	err := db.Select(&ids, `
+		SELECT p.id
+		FROM products_fts f
+		JOIN products p ON p.id = f.rowid
+		WHERE products_fts MATCH ? AND `+where+`
+		ORDER BY bm25(products_fts), p.name
+		LIMIT ?
+	`, append(append([]interface{}{strings.Join(match, " ")}, args...), limit)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search products: %w", err)
+	}
+
+	// 2. Tippfehlertolerante Suche für die restlichen Plätze
+	if len(ids) < limit {
+		fuzzy, err := fuzzyProductIDs(tokens, where, args, ids)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range fuzzy {
+			if len(ids) >= limit {
+				break
+			}
+			ids = append(ids, id)
+		}
+	}
+
+	products := make([]Product, 0, len(ids))
+	for _, id := range ids {
+		product, err := a.GetProduct(id)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, *product)
+	}
+	return products, nil
+}
+
+// fuzzyProductIDs findet Produkte, deren Namen alle Suchbegriffe mit wenigen
+// Tippfehlern enthalten; bereits gefundene IDs werden ausgelassen
+func fuzzyProductIDs(tokens []string, where string, args []interface{}, exclude []int) ([]int, error) {
+	var candidates []struct {
+		ID         int    `db:"id"`
+		Name       string `db:"name"`
+		Normalized string `db:"normalized"`
+	}
+	err := db.Select(&candidates, `
+		SELECT p.id, p.name, f.normalized
+		FROM products p
+		JOIN products_fts f ON f.rowid = p.id
+		WHERE `+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load search candidates: %w", err)
+	}
+
+	skip := map[int]bool{}
+	for _, id := range exclude {
+		skip[id] = true
+	}
+
+	type scored struct {
+		id    int
+		name  string
+		score int
+	}
+	var hits []scored
+	for _, c := range candidates {
+		if skip[c.ID] {
+			continue
+		}
+		words := strings.Fields(c.Normalized)
+		total := 0
+		matched := true
+		for _, token := range tokens {
+			best := -1
+			for _, word := range words {
+				if d := fuzzyWordDistance(token, word); d >= 0 && (best < 0 || d < best) {
+					best = d
+				}
+			}
+			if best < 0 {
+				matched = false
+				break
+			}
+			total += best
+		}
+		if matched {
+			hits = append(hits, scored{c.ID, c.Name, total})
+		}
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].score != hits[j].score {
+			return hits[i].score < hits[j].score
+		}
+		return hits[i].name < hits[j].name
+	})
+
+	ids := make([]int, 0, len(hits))
+	for _, h := range hits {
+		ids = append(ids, h.id)
+	}
+	return ids, nil
+}
+
+// fuzzyWordDistance vergleicht einen Suchbegriff mit einem Wort (auch als Wortanfang
+// oder Wortteil). Gibt eine Distanz zurück oder -1, wenn das Wort nicht passt.
+func fuzzyWordDistance(token, word string) int {
+	t, w := []rune(token), []rune(word)
+
+	// Kurze Begriffe müssen exakt am Wortanfang stehen
+	tolerance := 0
+	switch {
+	case len(t) >= 7:
+		tolerance = 2
+	case len(t) >= 4:
+		tolerance = 1
+	}
+
+	best := editDistance(t, w)
+	if len(w) > len(t) {
+		// Wortanfang vergleichen ("brotchn" → "broetchen")
+		for n := len(t) - tolerance; n <= len(t)+tolerance && n <= len(w); n++ {
+			if n <= 0 {
+				continue
+			}
+			if d := editDistance(t, w[:n]); d < best {
+				best = d
+			}
+		}
+	}
+
+	if best <= tolerance {
+		return best
+	}
+
+	// Wortteil in Komposita ("mus" → "apfelmus"), nachrangig gewertet
+	if len(t) >= 3 && strings.Contains(word, token) {
+		return tolerance + 1
+	}
+	return -1
+}
+
+// editDistance berechnet die Damerau-Levenshtein-Distanz (mit Vertauschungen)
+func editDistance(a, b []rune) int {
+	prev2 := make([]int, len(b)+1)
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(b)]
+}
+
+// syncSearchIndex baut den Volltextindex neu auf, wenn er nicht zu products passt
+func syncSearchIndex() error {
+	var products, indexed int
+	if err := db.Get(&products, "SELECT COUNT(*) FROM products"); err != nil {
+		return fmt.Errorf("failed to count products: %w", err)
+	}
+	if err := db.Get(&indexed, "SELECT COUNT(*) FROM products_fts"); err != nil {
+		return fmt.Errorf("failed to count search index: %w", err)
+	}
+	if products == indexed {
+		return nil
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM products_fts"); err != nil {
+		return fmt.Errorf("failed to clear search index: %w", err)
+	}
+	_, err = tx.Exec("INSERT INTO products_fts (rowid, normalized) SELECT id, normalize_de(name) FROM products")
+	if err != nil {
+		return fmt.Errorf("failed to rebuild search index: %w", err)
+	}
+
+	return tx.Commit()
+}
@@ -22,7 +22,8 @@ func SeedDatabase() error {
 
 	// Nur seeden wenn noch keine Daten vorhanden sind
 	if count > 0 {
-		return nil // Bereits geseedet
+		// Später hinzugekommene Stammdaten auch in bestehenden Datenbanken ergänzen
+		return seedAdditionalData()
 	}
 
 	// Allergene seeden
@@ -40,6 +41,131 @@ func SeedDatabase() error {
 		return fmt.Errorf("failed to seed products: %w", err)
 	}
 
+	return seedAdditionalData()
+}
+
+// seedAdditionalData ergänzt Stammdaten, die nach der ersten Version hinzugekommen sind.
+// Jeder Schritt prüft selbst, ob er bereits ausgeführt wurde.
+func seedAdditionalData() error {
+	if err := seedDGE(); err != nil {
+		return err
+	}
+	if err := seedCategories(); err != nil {
+		return fmt.Errorf("failed to seed categories: %w", err)
+	}
+	return nil
+}
+
+// seedDGE fügt Lebensmittelgruppen und DGE-Standardregeln ein, falls noch nicht vorhanden
+func seedDGE() error {
+	var count int
+	if err := db.Get(&count, "SELECT COUNT(*) FROM food_groups"); err != nil {
+		return fmt.Errorf("failed to check for existing food groups: %w", err)
+	}
+	if count > 0 {
+		return nil
+	}
+
+	if err := seedFoodGroups(); err != nil {
+		return fmt.Errorf("failed to seed food groups: %w", err)
+	}
+	if err := seedDGERules(); err != nil {
+		return fmt.Errorf("failed to seed DGE rules: %w", err)
+	}
+	return nil
+}
+
+// seedFoodGroups fügt die Lebensmittelgruppen ein und ordnet bekannte Produkte per Stichwort zu
+func seedFoodGroups() error {
+	groups := []FoodGroup{
+		{"getreide", "Getreide & Brot"},
+		{"vollkorn", "Vollkornprodukte"},
+		{"gemuese", "Gemüse & Salat"},
+		{"obst", "Obst"},
+		{"milch", "Milch & Milchprodukte"},
+		{"fleisch", "Fleisch & Wurst"},
+		{"fisch", "Fisch"},
+		{"ei", "Eier"},
+		{"suess", "Süßes & Gebäck"},
+		{"fett", "Fette & Öle"},
+	}
+
+	for _, group := range groups {
+		_, err := db.NamedExec("INSERT OR IGNORE INTO food_groups (id, name) VALUES (:id, :name)", group)
+		if err != nil {
+			return fmt.Errorf("failed to insert food group %s: %w", group.ID, err)
+		}
+	}
+
+	// Stichworte im Produktnamen → Lebensmittelgruppe
+	keywords := map[string][]string{
+		"getreide": {"brot", "brötchen", "baguette", "bag.", "toast", "bemmchen", "laugen", "brezel", "zwieback", "knäcke", "filinchen", "flocken", "cornflakes", "reiswaffel", "maisstangen", "pizzabrötchen"},
+		"vollkorn": {"vollkorn", "haferflocken", "knäcke"},
+		"gemuese":  {"gemüse", "rohkost", "salat"},
+		"obst":     {"obst", "apfel", "banane", "aprikose", "erdbeer", "frucht", "grütze"},
+		"milch":    {"milch", "joghurt", "quark", "käse", "pudding", "fruchtzwerge", "monte", "paula", "vanillesoße"},
+		"fleisch":  {"wiener", "wurst", "salami", "würstchen", "hot-dog", "frikadelle", "hawai"},
+		"ei":       {"eier", "rührei"},
+		"suess":    {"kuchen", "kekse", "donut", "muffin", "berliner", "amerikaner", "eclair", "windbeutel", "waffel", "nutella", "schoko", "süß", "süss", "marmelade", "götterspeise", "zimt", "madeleine", "leckermäulchen", "quarkbällchen", "fruchtriegel"},
+		"fett":     {"butter"},
+	}
+
+	var products []Product
+	if err := db.Select(&products, "SELECT id, name FROM products"); err != nil {
+		return fmt.Errorf("failed to load products: %w", err)
+	}
+
+	for _, product := range products {
+		name := strings.ToLower(product.Name)
+		for groupID, words := range keywords {
+			for _, word := range words {
+				if !strings.Contains(name, word) {
+					continue
+				}
+				_, err := db.Exec(
+					"INSERT OR IGNORE INTO product_food_groups (product_id, food_group_id) VALUES (?, ?)",
+					product.ID, groupID,
+				)
+				if err != nil {
+					return fmt.Errorf("failed to link food group %s to product %s: %w", groupID, product.Name, err)
+				}
+				break
+			}
+		}
+	}
+
+	return nil
+}
+
+// seedDGERules fügt die Standardregeln für Frühstück und Zwischenverpflegung
+// nach dem DGE-Qualitätsstandard für Kitas ein (bezogen auf 5 Verpflegungstage)
+func seedDGERules() error {
+	count := func(n int) *int { return &n }
+	rules := []DGERule{
+		{Name: "Gemüse oder Obst täglich", FoodGroups: []string{"gemuese", "obst"}, MinCount: count(5),
+			Explanation: "Gemüse, Salat oder Obst sollen bei jeder Verpflegung (5x in 5 Tagen) angeboten werden."},
+		{Name: "Getreideprodukte täglich", FoodGroups: []string{"getreide", "vollkorn"}, MinCount: count(5),
+			Explanation: "Getreide, Getreideprodukte oder Kartoffeln gehören täglich zum Angebot."},
+		{Name: "Vollkornprodukte mehrmals pro Woche", FoodGroups: []string{"vollkorn"}, MinCount: count(4),
+			Explanation: "Von den Getreideprodukten sollen mindestens 4 von 5 Angeboten Vollkornprodukte sein."},
+		{Name: "Milch und Milchprodukte täglich", FoodGroups: []string{"milch"}, MinCount: count(5),
+			Explanation: "Milch oder Milchprodukte (Joghurt, Quark, Käse) sollen täglich angeboten werden."},
+		{Name: "Fleisch und Wurst begrenzt", FoodGroups: []string{"fleisch"}, MaxCount: count(1),
+			Explanation: "Fleisch- und Wurstwaren sollen in der Zwischenverpflegung höchstens 1x in 5 Tagen vorkommen."},
+		{Name: "Süßes begrenzt", FoodGroups: []string{"suess"}, MaxCount: count(1),
+			Explanation: "Süßwaren, süße Backwaren und süße Aufstriche sollen die Ausnahme bleiben (höchstens 1x in 5 Tagen)."},
+	}
+
+	for _, rule := range rules {
+		_, err := db.Exec(`
+			INSERT INTO dge_rules (name, food_groups, meal, min_count, max_count, explanation, enabled)
+			VALUES (?, ?, ?, ?, ?, ?, TRUE)
+		`, rule.Name, strings.Join(rule.FoodGroups, ","), rule.Meal, rule.MinCount, rule.MaxCount, rule.Explanation)
+		if err != nil {
+			return fmt.Errorf("failed to insert DGE rule %s: %w", rule.Name, err)
+		}
+	}
+
 	return nil
 }
 
@@ -219,4 +345,69 @@ func removeDuplicates(slice []string) []string {
 	}
 
 	return result
-}
\ No newline at end of file
+}
+
+// Merker, dass die Standard-Kategorien einmal angelegt wurden
+const settingCategoriesSeeded = "categories_seeded"
+
+// seedCategories legt die Standard-Kategorien einmalig an. Der Merker in den Einstellungen
+// verhindert, dass gelöschte Kategorien beim nächsten Start wiederkommen.
+func seedCategories() error {
+	var seeded int
+	if err := db.Get(&seeded, "SELECT COUNT(*) FROM settings WHERE key = ?", settingCategoriesSeeded); err != nil {
+		return fmt.Errorf("failed to check category seed marker: %w", err)
+	}
+	if seeded > 0 {
+		return nil
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("INSERT INTO settings (key, value) VALUES (?, '1')", settingCategoriesSeeded); err != nil {
+		return fmt.Errorf("failed to set category seed marker: %w", err)
+	}
+
+	// Bestehende Installationen mit eigenen Kategorien bekommen keine Standardliste dazu
+	var count int
+	if err := tx.Get(&count, "SELECT COUNT(*) FROM categories"); err != nil {
+		return fmt.Errorf("failed to check for existing categories: %w", err)
+	}
+	if count > 0 {
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to commit transaction: %w", err)
+		}
+		return nil
+	}
+
+	categories := []struct {
+		name  string
+		color string
+	}{
+		{"Brot & Backwaren", "#C8A165"},
+		{"Obst & Gemüse", "#7CB342"},
+		{"Milchprodukte", "#64B5F6"},
+		{"Aufstrich", "#FFB74D"},
+		{"Wurst & Fleisch", "#E57373"},
+		{"Süßes & Desserts", "#BA68C8"},
+		{"Getränke", "#4DD0E1"},
+	}
+
+	for i, c := range categories {
+		_, err := tx.Exec(
+			"INSERT INTO categories (name, color, sort_order) VALUES (?, ?, ?)",
+			c.name, c.color, i,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert category %s: %w", c.name, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
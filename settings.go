@@ -0,0 +1,124 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Schlüssel der Einrichtungseinstellungen
+const settingOperatingDays = "operating_days"
+
+// defaultOperatingDays sind die Verpflegungstage ohne eigene Einstellung (Mo–Fr)
+var defaultOperatingDays = []int{1, 2, 3, 4, 5}
+
+// weekdayNames sind die deutschen Namen der Wochentage 1=Mo … 7=So
+var weekdayNames = []string{"Montag", "Dienstag", "Mittwoch", "Donnerstag", "Freitag", "Samstag", "Sonntag"}
+
+// BETRIEBSTAGE
+
+// GetOperatingDays gibt die Wochentage zurück, an denen die Einrichtung Verpflegung anbietet (1=Mo … 7=So)
+func (a *App) GetOperatingDays() ([]int, error) {
+	return operatingDays()
+}
+
+// SetOperatingDays legt die Betriebstage der Einrichtung fest. Bestehende Einträge
+// an künftig geschlossenen Tagen bleiben erhalten, werden aber nicht mehr ausgegeben.
+func (a *App) SetOperatingDays(days []int) ([]int, error) {
+	seen := map[int]bool{}
+	var normalized []int
+	for _, day := range days {
+		if err := validatePlanDay(day); err != nil {
+			return nil, err
+		}
+		if !seen[day] {
+			seen[day] = true
+			normalized = append(normalized, day)
+		}
+	}
+	if len(normalized) == 0 {
+		return nil, fmt.Errorf("at least one operating day is required")
+	}
+	sort.Ints(normalized)
+
+	parts := make([]string, len(normalized))
+	for i, day := range normalized {
+		parts[i] = strconv.Itoa(day)
+	}
+	_, err := db.Exec(`
+		INSERT INTO settings (key, value) VALUES (?, ?)
+		ON CONFLICT(key) DO UPDATE SET value = excluded.value
+	`, settingOperatingDays, strings.Join(parts, ","))
+	if err != nil {
+		return nil, fmt.Errorf("failed to save operating days: %w", err)
+	}
+	return normalized, nil
+}
+
+// HILFSFUNKTIONEN
+
+// operatingDays lädt die eingestellten Betriebstage, aufsteigend sortiert
+func operatingDays() ([]int, error) {
+	var value string
+	err := db.Get(&value, "SELECT value FROM settings WHERE key = ?", settingOperatingDays)
+	if err == sql.ErrNoRows {
+		return append([]int(nil), defaultOperatingDays...), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load operating days: %w", err)
+	}
+
+	var days []int
+	for _, part := range splitList(value) {
+		day, err := strconv.Atoi(part)
+		if err != nil || validatePlanDay(day) != nil {
+			return nil, fmt.Errorf("invalid operating days setting %q", value)
+		}
+		days = append(days, day)
+	}
+	if len(days) == 0 {
+		return append([]int(nil), defaultOperatingDays...), nil
+	}
+	sort.Ints(days)
+	return days, nil
+}
+
+// operatingDaySet gibt die Betriebstage als Menge zurück
+func operatingDaySet() (map[int]bool, error) {
+	days, err := operatingDays()
+	if err != nil {
+		return nil, err
+	}
+	set := make(map[int]bool, len(days))
+	for _, day := range days {
+		set[day] = true
+	}
+	return set, nil
+}
+
+// validateOperatingDay prüft, ob an einem Wochentag Verpflegung angeboten wird
+func validateOperatingDay(day int) error {
+	if err := validatePlanDay(day); err != nil {
+		return err
+	}
+	days, err := operatingDays()
+	if err != nil {
+		return err
+	}
+	for _, d := range days {
+		if d == day {
+			return nil
+		}
+	}
+	return fmt.Errorf("day %d is not an operating day", day)
+}
+
+// weekdayName gibt den deutschen Namen eines Wochentags zurück
+func weekdayName(day int) string {
+	if day < 1 || day > len(weekdayNames) {
+		return fmt.Sprintf("Tag %d", day)
+	}
+	return weekdayNames[day-1]
+}
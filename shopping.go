@@ -0,0 +1,259 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"speiseplan/isoweek"
+)
+
+// Gruppen für Produkte ohne Kategorie bzw. Lieferant und für Freitexte
+const (
+	shoppingNoCategory = "Ohne Kategorie"
+	shoppingNoSupplier = "Ohne Lieferant"
+)
+
+// EINKAUFSLISTE
+
+// GetShoppingList fasst den Bedarf aller Wochenpläne im Zeitraum opts.From–opts.To
+// (einschließlich) je Produkt zusammen, gruppiert nach Lieferant und oberster Kategorie. Der Vorrat aus
+// opts.Stock wird von der benötigten Menge abgezogen; ohne Angabe gilt der aktuelle Lagerbestand.
+func (a *App) GetShoppingList(opts ShoppingListOptions) (*ShoppingList, error) {
+	start, err := isoweek.ParseDate(opts.From)
+	if err != nil {
+		return nil, err
+	}
+	end, err := isoweek.ParseDate(opts.To)
+	if err != nil {
+		return nil, err
+	}
+	plans, err := a.GetPlansInRange(opts.From, opts.To)
+	if err != nil {
+		return nil, err
+	}
+	resolver, err := newCategoryColorResolver()
+	if err != nil {
+		return nil, err
+	}
+	suppliers, err := loadProductSuppliers()
+	if err != nil {
+		return nil, err
+	}
+	if opts.Stock == nil {
+		if opts.Stock, err = currentStock(); err != nil {
+			return nil, err
+		}
+	}
+
+	list := &ShoppingList{From: opts.From, To: opts.To, Weeks: []WeekRef{}, Groups: []ShoppingGroup{}, MissingPortionSize: []string{}}
+	items := map[string]*ShoppingItem{}
+	var order []string
+	for i := range plans {
+		plan := &plans[i]
+		list.Weeks = append(list.Weeks, WeekRef{Year: plan.Year, Week: plan.Week})
+
+		headcounts, err := weekHeadcounts(plan)
+		if err != nil {
+			return nil, err
+		}
+		report := buildPortionReport(plan, headcounts)
+
+		products := map[int]*Product{}
+		for j := range plan.Entries {
+			if p := plan.Entries[j].Product; p != nil {
+				products[p.ID] = p
+			}
+		}
+
+		for _, line := range report.Lines {
+			date := isoweek.Day(plan.Year, plan.Week, line.Day)
+			if date.Before(start) || date.After(end) {
+				continue
+			}
+
+			key := "text:" + line.Name
+			if line.ProductID != nil {
+				key = fmt.Sprintf("product:%d", *line.ProductID)
+			}
+			item, ok := items[key]
+			if !ok {
+				item = &ShoppingItem{ProductID: line.ProductID, Name: line.Name, Supplier: shoppingNoSupplier, Category: shoppingNoCategory, PortionSize: line.PortionSize}
+				if line.ProductID != nil {
+					if ps, ok := suppliers[*line.ProductID]; ok {
+						item.Supplier = ps.SupplierName
+					}
+					if p := products[*line.ProductID]; p != nil {
+						if c, ok := resolver[rootCategoryID(resolver, p)]; ok {
+							item.Category = c.Name
+						}
+					}
+				}
+				items[key] = item
+				order = append(order, key)
+			}
+			item.Portions += line.Portions
+			if line.Quantity != nil {
+				sum := *line.Quantity
+				if item.Required != nil {
+					sum += *item.Required
+				}
+				item.Required = &sum
+			}
+		}
+	}
+
+	groups := map[string]*ShoppingGroup{}
+	for _, key := range order {
+		item := items[key]
+		if item.ProductID != nil {
+			item.Stock = opts.Stock[*item.ProductID]
+			if item.PortionSize == nil {
+				list.MissingPortionSize = append(list.MissingPortionSize, item.Name)
+			}
+		}
+		if item.Required != nil {
+			toBuy := *item.Required - item.Stock
+			if toBuy < 0 {
+				toBuy = 0
+			}
+			item.ToBuy = &toBuy
+		}
+
+		groupKey := item.Supplier + "|" + item.Category
+		group, ok := groups[groupKey]
+		if !ok {
+			group = &ShoppingGroup{Supplier: item.Supplier, Category: item.Category}
+			groups[groupKey] = group
+		}
+		group.Items = append(group.Items, *item)
+	}
+
+	for _, group := range groups {
+		sort.Slice(group.Items, func(i, j int) bool {
+			return strings.ToLower(group.Items[i].Name) < strings.ToLower(group.Items[j].Name)
+		})
+		list.Groups = append(list.Groups, *group)
+	}
+	sort.Slice(list.Groups, func(i, j int) bool {
+		gi, gj := list.Groups[i], list.Groups[j]
+		if gi.Supplier != gj.Supplier {
+			return shoppingGroupLess(gi.Supplier, gj.Supplier, shoppingNoSupplier)
+		}
+		return shoppingGroupLess(gi.Category, gj.Category, shoppingNoCategory)
+	})
+	sort.Strings(list.MissingPortionSize)
+	return list, nil
+}
+
+// EXPORT
+
+// ExportShoppingListCSV exportiert die Einkaufsliste als CSV (Semikolon, für Excel)
+func (a *App) ExportShoppingListCSV(opts ShoppingListOptions, outputPath string) error {
+	list, err := a.GetShoppingList(opts)
+	if err != nil {
+		return err
+	}
+
+	f, w, err := newExcelCSV(outputPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := w.Write([]string{"Lieferant", "Kategorie", "Produkt", "Portionen", "Bedarf (g)", "Vorrat (g)", "Einkauf (g)"}); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+	for _, group := range list.Groups {
+		for _, item := range group.Items {
+			stock := item.Stock
+			record := []string{
+				group.Supplier,
+				group.Category,
+				item.Name,
+				fmt.Sprint(item.Portions),
+				formatQuantity(item.Required),
+				formatQuantity(&stock),
+				formatQuantity(item.ToBuy),
+			}
+			if err := w.Write(record); err != nil {
+				return fmt.Errorf("failed to write CSV record: %w", err)
+			}
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("failed to write CSV file: %w", err)
+	}
+	return f.Close()
+}
+
+// ExportShoppingListPDF exportiert die Einkaufsliste als PDF zum Abhaken (Hochformat A4)
+func (a *App) ExportShoppingListPDF(opts ShoppingListOptions, outputPath string) error {
+	list, err := a.GetShoppingList(opts)
+	if err != nil {
+		return err
+	}
+
+	pdf := newReportPDF("P")
+	pdf.AddPage()
+
+	pageW, _ := pdf.GetPageSize()
+	marginX := 10.0
+	usableW := pageW - 2*marginX
+
+	start, _ := isoweek.ParseDate(list.From)
+	end, _ := isoweek.ParseDate(list.To)
+	pdf.SetFont("DejaVu", "B", 14)
+	pdf.CellFormat(usableW, 8, "Einkaufsliste", "", 1, "L", false, 0, "")
+	pdf.SetFont("DejaVu", "", 9)
+	pdf.CellFormat(usableW, 5, fmt.Sprintf("%s – %s", start.Format("02.01.2006"), end.Format("02.01.2006")), "", 1, "L", false, 0, "")
+	pdf.Ln(3)
+
+	widths := []float64{8, usableW - 88, 20, 20, 20, 20}
+	headers := []string{"", "Produkt", "Portionen", "Bedarf (g)", "Vorrat (g)", "Einkauf (g)"}
+	for _, group := range list.Groups {
+		pdf.SetFont("DejaVu", "B", 10)
+		pdf.CellFormat(usableW, 7, group.Supplier+" – "+group.Category, "", 1, "L", false, 0, "")
+
+		pdf.SetFont("DejaVu", "B", 8)
+		pdf.SetFillColor(220, 220, 220)
+		for i, h := range headers {
+			pdf.CellFormat(widths[i], 6, h, "1", 0, "L", true, 0, "")
+		}
+		pdf.Ln(-1)
+
+		pdf.SetFont("DejaVu", "", 8)
+		for _, item := range group.Items {
+			stock := item.Stock
+			cells := []string{"", item.Name, fmt.Sprint(item.Portions), formatQuantity(item.Required), formatQuantity(&stock), formatQuantity(item.ToBuy)}
+			for i, text := range cells {
+				pdf.CellFormat(widths[i], 5, text, "1", 0, "L", false, 0, "")
+			}
+			pdf.Ln(-1)
+		}
+		pdf.Ln(2)
+	}
+
+	if len(list.Groups) == 0 {
+		pdf.SetFont("DejaVu", "", 9)
+		pdf.CellFormat(usableW, 6, "Keine geplanten Einträge im gewählten Zeitraum.", "", 1, "L", false, 0, "")
+	}
+	if len(list.MissingPortionSize) > 0 {
+		pdf.Ln(2)
+		pdf.SetFont("DejaVu", "", 8)
+		pdf.MultiCell(usableW, 4, "Ohne Portionsgröße (Menge nicht berechnet): "+strings.Join(list.MissingPortionSize, ", "), "", "L", false)
+	}
+
+	return pdf.OutputFileAndClose(outputPath)
+}
+
+// HILFSFUNKTIONEN
+
+// shoppingGroupLess sortiert Gruppennamen alphabetisch, die Sammelgruppe last zuletzt
+func shoppingGroupLess(a, b, last string) bool {
+	if (a == last) != (b == last) {
+		return b == last
+	}
+	return strings.ToLower(a) < strings.ToLower(b)
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Arten von Sondertagen
+const (
+	specialDayHoliday = "feiertag"
+	specialDayClosed  = "schliesstag"
+	specialDayOuting  = "ausflug"
+	specialDayTeam    = "teamtag"
+	specialDayBridge  = "brueckentag"
+)
+
+// specialDayTypeNames sind die Anzeigenamen der Sondertagsarten
+var specialDayTypeNames = map[string]string{
+	specialDayHoliday: "Feiertag",
+	specialDayClosed:  "Schließtag",
+	specialDayOuting:  "Ausflug",
+	specialDayTeam:    "Teamtag",
+	specialDayBridge:  "Brückentag",
+}
+
+// scopeList ist eine Liste von Mahlzeiten oder Gruppen, gespeichert als sortierte,
+// kommagetrennte Liste. Leer bedeutet „alle“.
+type scopeList []string
+
+// newScopeList bereinigt, entdoppelt und sortiert die Werte
+func newScopeList(values []string) scopeList {
+	seen := map[string]bool{}
+	out := scopeList{}
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" && !seen[v] {
+			seen[v] = true
+			out = append(out, v)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Scan liest die kommagetrennte Liste aus der Datenbank
+func (s *scopeList) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*s = scopeList{}
+	case string:
+		*s = newScopeList(splitList(v))
+	case []byte:
+		*s = newScopeList(splitList(string(v)))
+	default:
+		return fmt.Errorf("unsupported scope value %T", src)
+	}
+	return nil
+}
+
+// Value speichert die Liste in kanonischer Form, damit gleiche Bereiche gleich verglichen werden
+func (s scopeList) Value() (driver.Value, error) {
+	return s.String(), nil
+}
+
+// String gibt die kanonische, kommagetrennte Form zurück
+func (s scopeList) String() string {
+	return strings.Join(newScopeList(s), ",")
+}
+
+// MarshalJSON gibt eine leere Liste statt null aus
+func (s scopeList) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]string(newScopeList(s)))
+}
+
+// contains prüft, ob value im Bereich liegt; ein leerer Bereich umfasst alles
+func (s scopeList) contains(value string) bool {
+	if len(s) == 0 {
+		return true
+	}
+	for _, v := range s {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// BEREICHE
+
+// closesDay gibt an, ob der Sondertag den ganzen Tag für alle Gruppen belegt
+func (sd SpecialDay) closesDay() bool {
+	return len(sd.Meals) == 0 && len(sd.Groups) == 0
+}
+
+// blocksMeal gibt an, ob die Mahlzeit für alle Gruppen entfällt
+func (sd SpecialDay) blocksMeal(meal string) bool {
+	return len(sd.Groups) == 0 && sd.Meals.contains(meal)
+}
+
+// blocks gibt an, ob ein Eintrag für eine Gruppe (nil = alle Gruppen) zu dieser Mahlzeit entfällt.
+// Einträge für alle Gruppen entfallen nur, wenn der Sondertag alle Gruppen betrifft.
+func (sd SpecialDay) blocks(meal string, group *string) bool {
+	if !sd.Meals.contains(meal) {
+		return false
+	}
+	if len(sd.Groups) == 0 {
+		return true
+	}
+	return group != nil && sd.Groups.contains(*group)
+}
+
+// scopeKey identifiziert Tag und Bereich eines Sondertags. Pro Tag ist je Bereich nur
+// ein Sondertag erlaubt (Unique-Index idx_special_days_scope).
+func (sd SpecialDay) scopeKey() string {
+	return fmt.Sprintf("%d|%s|%s", sd.Day, sd.Meals, sd.Groups)
+}
+
+// displayLabel gibt die Bezeichnung oder ersatzweise den Namen der Sondertagsart zurück
+func (sd SpecialDay) displayLabel() string {
+	if sd.Label != nil && *sd.Label != "" {
+		return *sd.Label
+	}
+	if name, ok := specialDayTypeNames[sd.Type]; ok {
+		return name
+	}
+	return sd.Type
+}
+
+// HILFSFUNKTIONEN
+
+// validateSpecialDay prüft Art und Bereich eines Sondertags
+func validateSpecialDay(dtype string, meals []string) error {
+	if _, ok := specialDayTypeNames[dtype]; !ok {
+		return fmt.Errorf("invalid special day type %q", dtype)
+	}
+	for _, meal := range meals {
+		known := false
+		for _, m := range planMeals {
+			if m == strings.TrimSpace(meal) {
+				known = true
+			}
+		}
+		if !known {
+			return fmt.Errorf("invalid meal %q", meal)
+		}
+	}
+	return nil
+}
+
+// specialDayScope normalisiert den Bereich eines Sondertags; alle Mahlzeiten gewählt = ganzer Tag
+func specialDayScope(meals []string, groups []string) (scopeList, scopeList) {
+	mealScope, groupScope := newScopeList(meals), newScopeList(groups)
+	if len(mealScope) == len(planMeals) {
+		mealScope = scopeList{}
+	}
+	return mealScope, groupScope
+}
+
+// closingSpecial gibt den Sondertag zurück, der den ganzen Tag für alle Gruppen belegt (nil = keiner)
+func closingSpecial(specials []SpecialDay, day int) *SpecialDay {
+	for i := range specials {
+		if specials[i].Day == day && specials[i].closesDay() {
+			return &specials[i]
+		}
+	}
+	return nil
+}
+
+// blockingSpecial gibt den Sondertag zurück, wegen dem eine Mahlzeit für alle Gruppen entfällt (nil = keiner)
+func blockingSpecial(specials []SpecialDay, day int, meal string) *SpecialDay {
+	for i := range specials {
+		if specials[i].Day == day && specials[i].blocksMeal(meal) {
+			return &specials[i]
+		}
+	}
+	return nil
+}
+
+// entryBlocked gibt an, ob ein Eintrag für eine Gruppe wegen eines Sondertags entfällt
+func entryBlocked(specials []SpecialDay, day int, meal string, group *string) bool {
+	for _, sd := range specials {
+		if sd.Day == day && sd.blocks(meal, group) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,505 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"speiseplan/isoweek"
+)
+
+// supplierRow bildet eine Zeile aus suppliers ab (Liefertage kommagetrennt)
+type supplierRow struct {
+	ID             int    `db:"id"`
+	Name           string `db:"name"`
+	Contact        string `db:"contact"`
+	Email          string `db:"email"`
+	Phone          string `db:"phone"`
+	CustomerNumber string `db:"customer_number"`
+	DeliveryDays   string `db:"delivery_days"`
+	LeadTimeDays   int    `db:"lead_time_days"`
+}
+
+func (r supplierRow) toSupplier() Supplier {
+	days := []int{}
+	for _, part := range splitList(r.DeliveryDays) {
+		if day, err := strconv.Atoi(part); err == nil {
+			days = append(days, day)
+		}
+	}
+	return Supplier{
+		ID:             r.ID,
+		Name:           r.Name,
+		Contact:        r.Contact,
+		Email:          r.Email,
+		Phone:          r.Phone,
+		CustomerNumber: r.CustomerNumber,
+		DeliveryDays:   days,
+		LeadTimeDays:   r.LeadTimeDays,
+	}
+}
+
+// supplierColumns sind die Spalten für supplierRow
+const supplierColumns = "id, name, contact, email, phone, customer_number, delivery_days, lead_time_days"
+
+// orderFileUnsafe passt auf Zeichen, die in Dateinamen von Bestellungen ersetzt werden
+var orderFileUnsafe = regexp.MustCompile(`[^\pL\pN-]+`)
+
+// LIEFERANTEN
+
+// GetSuppliers gibt alle Lieferanten alphabetisch zurück
+func (a *App) GetSuppliers() ([]Supplier, error) {
+	var rows []supplierRow
+	if err := db.Select(&rows, "SELECT "+supplierColumns+" FROM suppliers ORDER BY name"); err != nil {
+		return nil, fmt.Errorf("failed to get suppliers: %w", err)
+	}
+
+	suppliers := make([]Supplier, 0, len(rows))
+	for _, r := range rows {
+		suppliers = append(suppliers, r.toSupplier())
+	}
+	return suppliers, nil
+}
+
+// SaveSupplier legt einen Lieferanten an (ID == 0) oder aktualisiert ihn
+func (a *App) SaveSupplier(supplier Supplier) (*Supplier, error) {
+	supplier.Name = strings.TrimSpace(supplier.Name)
+	if supplier.Name == "" {
+		return nil, fmt.Errorf("supplier name must not be empty")
+	}
+	if supplier.LeadTimeDays < 0 {
+		return nil, fmt.Errorf("lead time must not be negative")
+	}
+	seen := map[int]bool{}
+	var days []string
+	sort.Ints(supplier.DeliveryDays)
+	for _, day := range supplier.DeliveryDays {
+		if err := validatePlanDay(day); err != nil {
+			return nil, err
+		}
+		if !seen[day] {
+			seen[day] = true
+			days = append(days, strconv.Itoa(day))
+		}
+	}
+	deliveryDays := strings.Join(days, ",")
+
+	if supplier.ID == 0 {
+		result, err := db.Exec(`
+			INSERT INTO suppliers (name, contact, email, phone, customer_number, delivery_days, lead_time_days)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+		`, supplier.Name, supplier.Contact, supplier.Email, supplier.Phone, supplier.CustomerNumber, deliveryDays, supplier.LeadTimeDays)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create supplier: %w", err)
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get supplier ID: %w", err)
+		}
+		supplier.ID = int(id)
+	} else {
+		_, err := db.Exec(`
+			UPDATE suppliers
+			SET name = ?, contact = ?, email = ?, phone = ?, customer_number = ?, delivery_days = ?, lead_time_days = ?
+			WHERE id = ?
+		`, supplier.Name, supplier.Contact, supplier.Email, supplier.Phone, supplier.CustomerNumber, deliveryDays, supplier.LeadTimeDays, supplier.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update supplier: %w", err)
+		}
+	}
+
+	return getSupplier(supplier.ID)
+}
+
+// DeleteSupplier löscht einen Lieferanten samt seinen Produktzuordnungen
+func (a *App) DeleteSupplier(id int) error {
+	if _, err := db.Exec("DELETE FROM suppliers WHERE id = ?", id); err != nil {
+		return fmt.Errorf("failed to delete supplier: %w", err)
+	}
+	return nil
+}
+
+// PRODUKTZUORDNUNG
+
+// GetProductSupplier gibt den Lieferanten eines Produkts zurück (nil = keiner zugeordnet)
+func (a *App) GetProductSupplier(productID int) (*ProductSupplier, error) {
+	mapping, err := loadProductSuppliers()
+	if err != nil {
+		return nil, err
+	}
+	if ps, ok := mapping[productID]; ok {
+		return &ps, nil
+	}
+	return nil, nil
+}
+
+// GetSupplierProducts gibt alle einem Lieferanten zugeordneten Produkte zurück
+func (a *App) GetSupplierProducts(supplierID int) ([]ProductSupplier, error) {
+	products := []ProductSupplier{}
+	err := db.Select(&products, `
+		SELECT ps.product_id, p.name AS product_name, ps.supplier_id, s.name AS supplier_name, ps.article_number, ps.pack_size
+		FROM product_suppliers ps
+		JOIN products p ON p.id = ps.product_id
+		JOIN suppliers s ON s.id = ps.supplier_id
+		WHERE ps.supplier_id = ?
+		ORDER BY p.name
+	`, supplierID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get supplier products: %w", err)
+	}
+	return products, nil
+}
+
+// SetProductSupplier ordnet ein Produkt einem Lieferanten zu (supplierID == nil entfernt die
+// Zuordnung). packSize ist die Gebindegröße in g, nach der Bestellmengen aufgerundet werden.
+func (a *App) SetProductSupplier(productID int, supplierID *int, articleNumber string, packSize *float64) (*ProductSupplier, error) {
+	if packSize != nil && *packSize <= 0 {
+		return nil, fmt.Errorf("pack size must be positive")
+	}
+	if _, err := a.GetProduct(productID); err != nil {
+		return nil, err
+	}
+
+	if supplierID == nil {
+		if _, err := db.Exec("DELETE FROM product_suppliers WHERE product_id = ?", productID); err != nil {
+			return nil, fmt.Errorf("failed to remove product supplier: %w", err)
+		}
+		return nil, nil
+	}
+
+	_, err := db.Exec(`
+		INSERT INTO product_suppliers (product_id, supplier_id, article_number, pack_size) VALUES (?, ?, ?, ?)
+		ON CONFLICT(product_id) DO UPDATE SET
+			supplier_id = excluded.supplier_id, article_number = excluded.article_number, pack_size = excluded.pack_size
+	`, productID, *supplierID, strings.TrimSpace(articleNumber), packSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set product supplier: %w", err)
+	}
+	return a.GetProductSupplier(productID)
+}
+
+// BESTELLUNGEN
+
+// GetOrderProposal teilt den Bedarf eines Wochenplans in Bestellungen je Lieferant und
+// Liefertermin auf. Jede Position wird zum letzten Liefertag vor ihrem ersten Einsatz
+// geliefert; Lieferanten ohne Liefertage liefern einmal zum ersten Einsatz der Woche.
+// Die Bestellfrist ergibt sich aus Liefertermin minus Vorlaufzeit.
+func (a *App) GetOrderProposal(weekPlanID int) (*OrderProposal, error) {
+	report, err := a.GetPortionReport(weekPlanID)
+	if err != nil {
+		return nil, err
+	}
+	suppliers, err := a.GetSuppliers()
+	if err != nil {
+		return nil, err
+	}
+	mapping, err := loadProductSuppliers()
+	if err != nil {
+		return nil, err
+	}
+	return buildOrderProposal(report, suppliers, mapping, time.Now()), nil
+}
+
+// ExportSupplierOrdersPDF schreibt je Bestellung ein PDF in outputDir und gibt die Dateipfade zurück
+func (a *App) ExportSupplierOrdersPDF(weekPlanID int, outputDir string) ([]string, error) {
+	proposal, err := a.GetOrderProposal(weekPlanID)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := []string{}
+	for _, order := range proposal.Orders {
+		path := filepath.Join(outputDir, orderFileName(order, ".pdf"))
+		if err := writeOrderPDF(order, a.currentActor(), path); err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// ExportSupplierOrdersText schreibt je Bestellung eine Textdatei zum Einfügen in eine E-Mail
+// (Empfänger, Betreff, Text) in outputDir und gibt die Dateipfade zurück
+func (a *App) ExportSupplierOrdersText(weekPlanID int, outputDir string) ([]string, error) {
+	proposal, err := a.GetOrderProposal(weekPlanID)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := []string{}
+	for _, order := range proposal.Orders {
+		path := filepath.Join(outputDir, orderFileName(order, ".txt"))
+		if err := os.WriteFile(path, []byte(formatOrderText(order, a.currentActor())), 0644); err != nil {
+			return nil, fmt.Errorf("failed to write order file: %w", err)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// HILFSFUNKTIONEN
+
+// getSupplier lädt einen Lieferanten über seine ID
+func getSupplier(id int) (*Supplier, error) {
+	var row supplierRow
+	if err := db.Get(&row, "SELECT "+supplierColumns+" FROM suppliers WHERE id = ?", id); err != nil {
+		return nil, fmt.Errorf("failed to get supplier: %w", err)
+	}
+	supplier := row.toSupplier()
+	return &supplier, nil
+}
+
+// loadProductSuppliers lädt alle Produktzuordnungen, nach Produkt-ID
+func loadProductSuppliers() (map[int]ProductSupplier, error) {
+	var rows []ProductSupplier
+	err := db.Select(&rows, `
+		SELECT ps.product_id, p.name AS product_name, ps.supplier_id, s.name AS supplier_name, ps.article_number, ps.pack_size
+		FROM product_suppliers ps
+		JOIN products p ON p.id = ps.product_id
+		JOIN suppliers s ON s.id = ps.supplier_id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load product suppliers: %w", err)
+	}
+
+	mapping := map[int]ProductSupplier{}
+	for _, r := range rows {
+		mapping[r.ProductID] = r
+	}
+	return mapping, nil
+}
+
+// buildOrderProposal fasst die Bedarfszeilen je Lieferant, Liefertermin und Produkt zusammen
+func buildOrderProposal(report *PortionReport, suppliers []Supplier, mapping map[int]ProductSupplier, now time.Time) *OrderProposal {
+	proposal := &OrderProposal{WeekPlanID: report.WeekPlanID, Year: report.Year, Week: report.Week, Orders: []SupplierOrder{}, Unassigned: []string{}}
+
+	byID := map[int]Supplier{}
+	for _, s := range suppliers {
+		byID[s.ID] = s
+	}
+
+	// Erster Einsatz je Lieferant, für Lieferanten ohne feste Liefertage
+	firstUse := map[int]int{}
+	for _, line := range report.Lines {
+		if line.ProductID == nil || line.Portions == 0 {
+			continue
+		}
+		if ps, ok := mapping[*line.ProductID]; ok {
+			if day, seen := firstUse[ps.SupplierID]; !seen || line.Day < day {
+				firstUse[ps.SupplierID] = line.Day
+			}
+		}
+	}
+
+	orders := map[string]*SupplierOrder{}
+	positions := map[string]int{} // Index der Position in ihrer Bestellung
+	var orderKeys []string
+	unassigned := map[string]bool{}
+	for _, line := range report.Lines {
+		if line.Portions == 0 {
+			continue
+		}
+		var ps ProductSupplier
+		ok := false
+		if line.ProductID != nil {
+			ps, ok = mapping[*line.ProductID]
+		}
+		if !ok {
+			if !unassigned[line.Name] {
+				unassigned[line.Name] = true
+				proposal.Unassigned = append(proposal.Unassigned, line.Name)
+			}
+			continue
+		}
+		supplier := byID[ps.SupplierID]
+
+		useDay := line.Day
+		if len(supplier.DeliveryDays) == 0 {
+			useDay = firstUse[supplier.ID]
+		}
+		delivery := deliveryDate(isoweek.Day(report.Year, report.Week, useDay), supplier.DeliveryDays)
+
+		orderKey := fmt.Sprintf("%s|%d", isoweek.FormatDate(delivery), supplier.ID)
+		order, exists := orders[orderKey]
+		if !exists {
+			orderBy := delivery.AddDate(0, 0, -supplier.LeadTimeDays)
+			order = &SupplierOrder{
+				Supplier:     supplier,
+				DeliveryDate: isoweek.FormatDate(delivery),
+				OrderBy:      isoweek.FormatDate(orderBy),
+				Overdue:      isoweek.FormatDate(orderBy) < now.Format(isoweek.DateLayout),
+			}
+			orders[orderKey] = order
+			orderKeys = append(orderKeys, orderKey)
+		}
+
+		lineKey := fmt.Sprintf("%s|%d", orderKey, ps.ProductID)
+		index, exists := positions[lineKey]
+		if !exists {
+			index = len(order.Lines)
+			positions[lineKey] = index
+			order.Lines = append(order.Lines, OrderLine{ProductID: ps.ProductID, Name: line.Name, ArticleNumber: ps.ArticleNumber, PackSize: ps.PackSize})
+		}
+		position := &order.Lines[index]
+		position.Portions += line.Portions
+		if line.Quantity != nil {
+			sum := *line.Quantity
+			if position.Required != nil {
+				sum += *position.Required
+			}
+			position.Required = &sum
+		}
+	}
+
+	sort.Strings(orderKeys)
+	for _, key := range orderKeys {
+		order := orders[key]
+		for i := range order.Lines {
+			l := &order.Lines[i]
+			if l.Required != nil && l.PackSize != nil {
+				packs := int(math.Ceil(*l.Required / *l.PackSize))
+				l.Packs = &packs
+			}
+		}
+		sort.Slice(order.Lines, func(i, j int) bool {
+			return strings.ToLower(order.Lines[i].Name) < strings.ToLower(order.Lines[j].Name)
+		})
+		proposal.Orders = append(proposal.Orders, *order)
+	}
+	sort.SliceStable(proposal.Orders, func(i, j int) bool {
+		if proposal.Orders[i].DeliveryDate != proposal.Orders[j].DeliveryDate {
+			return proposal.Orders[i].DeliveryDate < proposal.Orders[j].DeliveryDate
+		}
+		return strings.ToLower(proposal.Orders[i].Supplier.Name) < strings.ToLower(proposal.Orders[j].Supplier.Name)
+	})
+	sort.Strings(proposal.Unassigned)
+	return proposal
+}
+
+// deliveryDate gibt den letzten Liefertag am oder vor dem Einsatztag zurück
+// (ohne Liefertage: den Einsatztag selbst)
+func deliveryDate(use time.Time, deliveryDays []int) time.Time {
+	if len(deliveryDays) == 0 {
+		return use
+	}
+	for back := 0; back < 7; back++ {
+		d := use.AddDate(0, 0, -back)
+		if containsInt(deliveryDays, isoweek.Weekday(d)) {
+			return d
+		}
+	}
+	return use
+}
+
+// orderFileName bildet einen Dateinamen aus Lieferant und Liefertermin
+func orderFileName(order SupplierOrder, ext string) string {
+	name := strings.Trim(orderFileUnsafe.ReplaceAllString(order.Supplier.Name, "_"), "_")
+	return fmt.Sprintf("Bestellung_%s_%s%s", name, order.DeliveryDate, ext)
+}
+
+// formatOrderText erstellt den E-Mail-Text einer Bestellung
+func formatOrderText(order SupplierOrder, actor string) string {
+	delivery, _ := isoweek.ParseDate(order.DeliveryDate)
+
+	var b strings.Builder
+	if order.Supplier.Email != "" {
+		fmt.Fprintf(&b, "An: %s\n", order.Supplier.Email)
+	}
+	subject := fmt.Sprintf("Bestellung zur Lieferung am %s, %s", weekdayName(isoweek.Weekday(delivery)), delivery.Format("02.01.2006"))
+	if order.Supplier.CustomerNumber != "" {
+		subject += fmt.Sprintf(" (Kundennr. %s)", order.Supplier.CustomerNumber)
+	}
+	fmt.Fprintf(&b, "Betreff: %s\n\n", subject)
+
+	if order.Supplier.Contact != "" {
+		fmt.Fprintf(&b, "Guten Tag %s,\n\n", order.Supplier.Contact)
+	} else {
+		b.WriteString("Sehr geehrte Damen und Herren,\n\n")
+	}
+	fmt.Fprintf(&b, "hiermit bestellen wir zur Lieferung am %s:\n\n", delivery.Format("02.01.2006"))
+	for _, l := range order.Lines {
+		b.WriteString("- ")
+		b.WriteString(formatOrderQuantity(l))
+		b.WriteString(" ")
+		b.WriteString(l.Name)
+		if l.ArticleNumber != "" {
+			fmt.Fprintf(&b, " (Art.-Nr. %s)", l.ArticleNumber)
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\nVielen Dank und freundliche Grüße\n")
+	b.WriteString(actor)
+	b.WriteString("\n")
+	return b.String()
+}
+
+// formatOrderQuantity gibt die Bestellmenge in Gebinden bzw. g aus
+func formatOrderQuantity(l OrderLine) string {
+	switch {
+	case l.Packs != nil:
+		return fmt.Sprintf("%d × %s g", *l.Packs, formatQuantity(l.PackSize))
+	case l.Required != nil:
+		return formatQuantity(l.Required) + " g"
+	default:
+		return fmt.Sprintf("für %d Portionen", l.Portions)
+	}
+}
+
+// writeOrderPDF schreibt eine Bestellung als PDF (Hochformat A4)
+func writeOrderPDF(order SupplierOrder, actor string, outputPath string) error {
+	delivery, _ := isoweek.ParseDate(order.DeliveryDate)
+	orderBy, _ := isoweek.ParseDate(order.OrderBy)
+
+	pdf := newReportPDF("P")
+	pdf.AddPage()
+
+	pageW, _ := pdf.GetPageSize()
+	marginX := 10.0
+	usableW := pageW - 2*marginX
+
+	pdf.SetFont("DejaVu", "B", 14)
+	pdf.CellFormat(usableW, 8, "Bestellung", "", 1, "L", false, 0, "")
+	pdf.SetFont("DejaVu", "", 10)
+	info := []string{order.Supplier.Name}
+	if order.Supplier.Contact != "" {
+		info = append(info, "z. Hd. "+order.Supplier.Contact)
+	}
+	if order.Supplier.CustomerNumber != "" {
+		info = append(info, "Kundennummer: "+order.Supplier.CustomerNumber)
+	}
+	info = append(info,
+		fmt.Sprintf("Lieferung am: %s, %s", weekdayName(isoweek.Weekday(delivery)), delivery.Format("02.01.2006")),
+		fmt.Sprintf("Bestellen bis: %s", orderBy.Format("02.01.2006")),
+	)
+	for _, line := range info {
+		pdf.CellFormat(usableW, 5, line, "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(4)
+
+	widths := []float64{30, usableW - 90, 30, 30}
+	headers := []string{"Art.-Nr.", "Produkt", "Bedarf (g)", "Menge"}
+	pdf.SetFont("DejaVu", "B", 9)
+	pdf.SetFillColor(220, 220, 220)
+	for i, h := range headers {
+		pdf.CellFormat(widths[i], 6, h, "1", 0, "L", true, 0, "")
+	}
+	pdf.Ln(-1)
+	pdf.SetFont("DejaVu", "", 9)
+	for _, l := range order.Lines {
+		cells := []string{l.ArticleNumber, l.Name, formatQuantity(l.Required), formatOrderQuantity(l)}
+		for i, text := range cells {
+			pdf.CellFormat(widths[i], 6, text, "1", 0, "L", false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+
+	pdf.Ln(6)
+	pdf.CellFormat(usableW, 5, fmt.Sprintf("Erstellt am %s von %s", time.Now().Format("02.01.2006"), actor), "", 1, "L", false, 0, "")
+	return pdf.OutputFileAndClose(outputPath)
+}
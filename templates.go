@@ -0,0 +1,338 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// VORLAGEN
+
+// GetTemplates gibt alle eigenständigen Wochenvorlagen zurück (ohne Rotationswochen)
+func (a *App) GetTemplates() ([]TemplateWeek, error) {
+	var ids []int
+	err := db.Select(&ids, "SELECT id FROM template_weeks WHERE rotation_id IS NULL ORDER BY name COLLATE NOCASE")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get templates: %w", err)
+	}
+
+	templates := []TemplateWeek{}
+	for _, id := range ids {
+		tw, err := a.getTemplateWeek(id)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, *tw)
+	}
+	return templates, nil
+}
+
+// GetTemplate gibt eine Vorlagenwoche mit ihren Einträgen zurück
+func (a *App) GetTemplate(id int) (*TemplateWeek, error) {
+	return a.getTemplateWeek(id)
+}
+
+// CreateTemplate legt eine leere Wochenvorlage an
+func (a *App) CreateTemplate(name string) (*TemplateWeek, error) {
+	name, err := validateTemplateName(0, name)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := db.Exec("INSERT INTO template_weeks (name) VALUES (?)", name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create template: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get template ID: %w", err)
+	}
+	return a.getTemplateWeek(int(id))
+}
+
+// RenameTemplate benennt eine Vorlagenwoche um (auch Rotationswochen)
+func (a *App) RenameTemplate(id int, name string) (*TemplateWeek, error) {
+	tw, err := a.getTemplateWeek(id)
+	if err != nil {
+		return nil, err
+	}
+	if tw.RotationID == nil {
+		if name, err = validateTemplateName(id, name); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := db.Exec("UPDATE template_weeks SET name = ? WHERE id = ?", strings.TrimSpace(name), id); err != nil {
+		return nil, fmt.Errorf("failed to rename template: %w", err)
+	}
+	return a.getTemplateWeek(id)
+}
+
+// DeleteTemplate löscht eine eigenständige Wochenvorlage
+func (a *App) DeleteTemplate(id int) error {
+	res, err := db.Exec("DELETE FROM template_weeks WHERE id = ? AND rotation_id IS NULL", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete template: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("template %d not found or part of a rotation", id)
+	}
+	return nil
+}
+
+// SaveWeekAsTemplate speichert die Einträge eines Wochenplans als neue Vorlage.
+// Sondertage gehören zum Kalender und werden nicht übernommen.
+func (a *App) SaveWeekAsTemplate(year int, week int, name string) (*TemplateWeek, error) {
+	name, err := validateTemplateName(0, name)
+	if err != nil {
+		return nil, err
+	}
+	plan, err := a.GetWeekPlan(year, week)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("INSERT INTO template_weeks (name) VALUES (?)", name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create template: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get template ID: %w", err)
+	}
+
+	if err := fillTemplateWeek(tx, int(id), plan.Entries); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return a.getTemplateWeek(int(id))
+}
+
+// ApplyTemplate überträgt eine Vorlage in eine Kalenderwoche. Die Konfliktmodi
+// entsprechen CopyWeekPlanWithMode: "fail", "overwrite" oder "merge"; Sondertage der
+// Zielwoche bleiben erhalten und sperren die betroffenen Vorlageneinträge.
+func (a *App) ApplyTemplate(templateID int, year int, week int, mode string) (*CopyResult, error) {
+	mode, err := validateConflictMode(mode)
+	if err != nil {
+		return nil, err
+	}
+	tw, err := a.getTemplateWeek(templateID)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]PlanEntry, 0, len(tw.Entries))
+	for _, e := range tw.Entries {
+		entries = append(entries, PlanEntry{
+			Day:        e.Day,
+			Meal:       e.Meal,
+			Slot:       e.Slot,
+			ProductID:  e.ProductID,
+			CustomText: e.CustomText,
+			GroupLabel: e.GroupLabel,
+		})
+	}
+
+	return a.copyIntoWeek(entries, nil, false, year, week, mode)
+}
+
+// VORLAGENWOCHEN
+
+// AddTemplateEntry fügt einer Vorlagenwoche einen Eintrag am Ende der Mahlzeit hinzu
+func (a *App) AddTemplateEntry(templateWeekID int, day int, meal string, productID *int, customText *string, groupLabel *string) (*TemplateEntry, error) {
+	if err := validateOperatingDay(day); err != nil {
+		return nil, err
+	}
+	if err := validatePlanMeal(meal); err != nil {
+		return nil, err
+	}
+
+	result, err := db.Exec(`
+		INSERT INTO template_entries (template_week_id, day, meal, slot, product_id, custom_text, group_label)
+		VALUES (?, ?, ?, (
+			SELECT COALESCE(MAX(slot), -1) + 1 FROM template_entries
+			WHERE template_week_id = ? AND day = ? AND meal = ?
+		), ?, ?, ?)
+	`, templateWeekID, day, meal, templateWeekID, day, meal, productID, customText, groupLabel)
+	if err != nil {
+		return nil, fmt.Errorf("failed to add template entry: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get template entry ID: %w", err)
+	}
+
+	var entry TemplateEntry
+	err = db.Get(&entry, `
+		SELECT id, template_week_id, day, meal, slot, product_id, custom_text, group_label
+		FROM template_entries WHERE id = ?
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get template entry: %w", err)
+	}
+	if entry.ProductID != nil {
+		if entry.Product, err = a.GetProduct(*entry.ProductID); err != nil {
+			return nil, err
+		}
+	}
+	return &entry, nil
+}
+
+// RemoveTemplateEntry entfernt einen Eintrag aus einer Vorlagenwoche
+func (a *App) RemoveTemplateEntry(id int) error {
+	_, err := db.Exec("DELETE FROM template_entries WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to remove template entry: %w", err)
+	}
+	return nil
+}
+
+// FillTemplateWeekFromPlan ersetzt den Inhalt einer Vorlagenwoche durch die Einträge eines Wochenplans
+func (a *App) FillTemplateWeekFromPlan(templateWeekID int, year int, week int) (*TemplateWeek, error) {
+	plan, err := a.GetWeekPlan(year, week)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fillTemplateWeek(tx, templateWeekID, plan.Entries); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return a.getTemplateWeek(templateWeekID)
+}
+
+// HILFSFUNKTIONEN
+
+// validateTemplateName prüft, ob ein Vorlagenname gesetzt und noch frei ist
+func validateTemplateName(id int, name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", fmt.Errorf("template name must not be empty")
+	}
+
+	var taken int
+	err := db.Get(&taken, `
+		SELECT COUNT(*) FROM template_weeks
+		WHERE rotation_id IS NULL AND id != ? AND name = ? COLLATE NOCASE
+	`, id, name)
+	if err != nil {
+		return "", fmt.Errorf("failed to check template name: %w", err)
+	}
+	if taken > 0 {
+		return "", fmt.Errorf("template %q already exists", name)
+	}
+	return name, nil
+}
+
+// getTemplateWeek lädt eine Vorlagenwoche mit ihren Einträgen
+func (a *App) getTemplateWeek(id int) (*TemplateWeek, error) {
+	var tw TemplateWeek
+	err := db.Get(&tw, "SELECT id, name, rotation_id, position, created_at FROM template_weeks WHERE id = ?", id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get template week: %w", err)
+	}
+
+	if tw.Entries, err = a.loadTemplateEntries(id); err != nil {
+		return nil, err
+	}
+	return &tw, nil
+}
+
+// loadTemplateEntries lädt die Einträge einer Vorlagenwoche
+func (a *App) loadTemplateEntries(templateWeekID int) ([]TemplateEntry, error) {
+	entries := []TemplateEntry{}
+	err := db.Select(&entries, `
+		SELECT id, template_week_id, day, meal, slot, product_id, custom_text, group_label
+		FROM template_entries
+		WHERE template_week_id = ?
+		ORDER BY day, meal, slot
+	`, templateWeekID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load template entries: %w", err)
+	}
+
+	for i := range entries {
+		if entries[i].ProductID == nil {
+			continue
+		}
+		if entries[i].Product, err = a.GetProduct(*entries[i].ProductID); err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
+// fillTemplateWeek ersetzt die Einträge einer Vorlagenwoche durch Planeinträge
+func fillTemplateWeek(tx *sqlx.Tx, templateWeekID int, entries []PlanEntry) error {
+	if _, err := tx.Exec("DELETE FROM template_entries WHERE template_week_id = ?", templateWeekID); err != nil {
+		return fmt.Errorf("failed to clear template week: %w", err)
+	}
+
+	for _, e := range entries {
+		_, err := tx.Exec(`
+			INSERT INTO template_entries (template_week_id, day, meal, slot, product_id, custom_text, group_label)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+		`, templateWeekID, e.Day, e.Meal, e.Slot, e.ProductID, e.CustomText, e.GroupLabel)
+		if err != nil {
+			return fmt.Errorf("failed to save template entry: %w", err)
+		}
+	}
+	return nil
+}
+
+// insertTemplateEntries überträgt die Einträge einer Vorlagenwoche in einen Wochenplan.
+// Einträge an Tagen ohne Verpflegung oder solche, die wegen eines der specials entfallen,
+// werden ausgelassen; nextSlot enthält je "Tag/Mahlzeit" die nächste freie Position und wird
+// fortgeschrieben. Gibt die IDs der neuen Planeinträge zurück.
+func insertTemplateEntries(tx *sqlx.Tx, entries []TemplateEntry, weekPlanID int, specials []SpecialDay, nextSlot map[string]int) ([]int, error) {
+	operating, err := operatingDaySet()
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []int
+	for _, e := range entries {
+		if !operating[e.Day] || entryBlocked(specials, e.Day, e.Meal, e.GroupLabel) {
+			continue
+		}
+
+		group := fmt.Sprintf("%d/%s", e.Day, e.Meal)
+		result, err := tx.Exec(`
+			INSERT INTO plan_entries (week_plan_id, day, meal, slot, product_id, product_revision_id, custom_text, group_label)
+			VALUES (?, ?, ?, ?, ?, `+currentRevisionSQL+`, ?, ?)
+		`, weekPlanID, e.Day, e.Meal, nextSlot[group], e.ProductID, e.ProductID, e.CustomText, e.GroupLabel)
+		if err != nil {
+			return nil, fmt.Errorf("failed to insert plan entry from template: %w", err)
+		}
+		nextSlot[group]++
+
+		id, err := result.LastInsertId()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get entry ID: %w", err)
+		}
+		ids = append(ids, int(id))
+	}
+	return ids, nil
+}
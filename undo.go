@@ -0,0 +1,269 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// maxUndoSteps begrenzt die Anzahl rückgängig machbarer Schritte pro Sitzung
+const maxUndoSteps = 100
+
+// planChange beschreibt eine Änderung an einem Wochenplan als Zustand der
+// betroffenen Planeinträge und Sondertage vorher/nachher
+type planChange struct {
+	weekPlanID int
+	entries    []entryChange
+	specials   []specialChange
+}
+
+// entryChange hält den Zustand eines Planeintrags vorher/nachher (nil = existiert nicht)
+type entryChange struct {
+	id     int
+	before *PlanEntry
+	after  *PlanEntry
+}
+
+// specialChange hält den Sondertag eines Tages und Bereichs vorher/nachher (nil = kein Sondertag)
+type specialChange struct {
+	day    int
+	meals  scopeList
+	groups scopeList
+	before *SpecialDay
+	after  *SpecialDay
+}
+
+// planHistory hält Undo- und Redo-Stapel der laufenden Sitzung
+type planHistory struct {
+	mu   sync.Mutex
+	undo []planChange
+	redo []planChange
+}
+
+// RÜCKGÄNGIG & WIEDERHOLEN
+
+// Undo macht die letzte Änderung an einem Wochenplan rückgängig und gibt den Plan zurück
+func (a *App) Undo() (*WeekPlan, error) {
+	a.history.mu.Lock()
+	defer a.history.mu.Unlock()
+
+	if len(a.history.undo) == 0 {
+		return nil, fmt.Errorf("nothing to undo")
+	}
+	change := a.history.undo[len(a.history.undo)-1]
+
+	if err := a.applyPlanChange(change, false); err != nil {
+		return nil, err
+	}
+	a.history.undo = a.history.undo[:len(a.history.undo)-1]
+	a.history.redo = append(a.history.redo, change)
+
+	return a.getWeekPlanByID(db, change.weekPlanID)
+}
+
+// Redo stellt die zuletzt rückgängig gemachte Änderung wieder her und gibt den Plan zurück
+func (a *App) Redo() (*WeekPlan, error) {
+	a.history.mu.Lock()
+	defer a.history.mu.Unlock()
+
+	if len(a.history.redo) == 0 {
+		return nil, fmt.Errorf("nothing to redo")
+	}
+	change := a.history.redo[len(a.history.redo)-1]
+
+	if err := a.applyPlanChange(change, true); err != nil {
+		return nil, err
+	}
+	a.history.redo = a.history.redo[:len(a.history.redo)-1]
+	a.history.undo = append(a.history.undo, change)
+
+	return a.getWeekPlanByID(db, change.weekPlanID)
+}
+
+// CanUndo gibt an, ob es eine Änderung zum Rückgängigmachen gibt
+func (a *App) CanUndo() bool {
+	a.history.mu.Lock()
+	defer a.history.mu.Unlock()
+	return len(a.history.undo) > 0
+}
+
+// CanRedo gibt an, ob es eine Änderung zum Wiederherstellen gibt
+func (a *App) CanRedo() bool {
+	a.history.mu.Lock()
+	defer a.history.mu.Unlock()
+	return len(a.history.redo) > 0
+}
+
+// HILFSFUNKTIONEN
+
+// recordPlanChange legt eine neue Änderung auf den Undo-Stapel; der Redo-Stapel verfällt
+func (a *App) recordPlanChange(change planChange) {
+	a.history.mu.Lock()
+	defer a.history.mu.Unlock()
+
+	a.history.undo = append(a.history.undo, change)
+	if len(a.history.undo) > maxUndoSteps {
+		a.history.undo = a.history.undo[len(a.history.undo)-maxUndoSteps:]
+	}
+	a.history.redo = nil
+}
+
+// forgetPlan entfernt alle Schritte eines gelöschten Wochenplans aus Undo und Redo
+func (h *planHistory) forgetPlan(weekPlanID int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	keep := func(changes []planChange) []planChange {
+		var out []planChange
+		for _, c := range changes {
+			if c.weekPlanID != weekPlanID {
+				out = append(out, c)
+			}
+		}
+		return out
+	}
+	h.undo = keep(h.undo)
+	h.redo = keep(h.redo)
+}
+
+// applyPlanChange stellt den Zustand vor (forward = false) bzw. nach der Änderung her
+func (a *App) applyPlanChange(change planChange, forward bool) error {
+	action := auditActionUndo
+	if forward {
+		action = auditActionRedo
+	}
+	if err := ensurePlanEditable(change.weekPlanID); err != nil {
+		return err
+	}
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Erst alle betroffenen Einträge entfernen, dann in Slot-Reihenfolge neu einfügen
+	var restore []*PlanEntry
+	for _, c := range change.entries {
+		if _, err := tx.Exec("DELETE FROM plan_entries WHERE id = ?", c.id); err != nil {
+			return fmt.Errorf("failed to remove plan entry: %w", err)
+		}
+		if _, state := c.states(forward); state != nil {
+			restore = append(restore, state)
+		}
+	}
+	if err := renumberSlots(tx, "week_plan_id = ?", change.weekPlanID); err != nil {
+		return err
+	}
+	sort.SliceStable(restore, func(i, j int) bool {
+		if restore[i].Day != restore[j].Day {
+			return restore[i].Day < restore[j].Day
+		}
+		if restore[i].Meal != restore[j].Meal {
+			return restore[i].Meal < restore[j].Meal
+		}
+		return restore[i].Slot < restore[j].Slot
+	})
+	for _, entry := range restore {
+		if err := restorePlanEntry(tx, entry); err != nil {
+			return err
+		}
+	}
+
+	for _, c := range change.specials {
+		_, state := c.states(forward)
+		if err := restoreSpecialDay(tx, change.weekPlanID, c, state); err != nil {
+			return err
+		}
+	}
+
+	for _, c := range change.entries {
+		prev, state := c.states(forward)
+		if err := a.audit(tx, action, auditEntityPlanEntry, c.id, &change.weekPlanID, prev, state); err != nil {
+			return err
+		}
+	}
+	for _, c := range change.specials {
+		prev, state := c.states(forward)
+		id := 0
+		if state != nil {
+			id = state.ID
+		} else if prev != nil {
+			id = prev.ID
+		}
+		if err := a.audit(tx, action, auditEntitySpecialDay, id, &change.weekPlanID, prev, state); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// states gibt den aktuellen und den herzustellenden Zustand zurück
+func (c entryChange) states(forward bool) (*PlanEntry, *PlanEntry) {
+	if forward {
+		return c.before, c.after
+	}
+	return c.after, c.before
+}
+
+// newSpecialChange leitet Tag und Bereich der Änderung aus dem vorhandenen Zustand ab
+func newSpecialChange(before, after *SpecialDay) specialChange {
+	key := after
+	if key == nil {
+		key = before
+	}
+	return specialChange{day: key.Day, meals: key.Meals, groups: key.Groups, before: before, after: after}
+}
+
+// states gibt den aktuellen und den herzustellenden Zustand zurück
+func (c specialChange) states(forward bool) (*SpecialDay, *SpecialDay) {
+	if forward {
+		return c.before, c.after
+	}
+	return c.after, c.before
+}
+
+// restorePlanEntry fügt einen gespeicherten Planeintrag mit seiner ID an seinem Slot wieder ein
+func restorePlanEntry(tx *sqlx.Tx, entry *PlanEntry) error {
+	slot, err := openSlot(tx, entry.WeekPlanID, entry.Day, entry.Meal, entry.Slot)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO plan_entries
+			(id, week_plan_id, day, meal, slot, product_id, product_revision_id, custom_text, group_label)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, entry.ID, entry.WeekPlanID, entry.Day, entry.Meal, slot, entry.ProductID, entry.ProductRevisionID, entry.CustomText, entry.GroupLabel)
+	if err != nil {
+		return fmt.Errorf("failed to restore plan entry: %w", err)
+	}
+	return nil
+}
+
+// restoreSpecialDay setzt den Sondertag eines Tages und Bereichs auf einen gespeicherten Zustand (nil = entfernen)
+func restoreSpecialDay(tx *sqlx.Tx, weekPlanID int, c specialChange, special *SpecialDay) error {
+	_, err := tx.Exec("DELETE FROM special_days WHERE week_plan_id = ? AND day = ? AND meals = ? AND groups = ?",
+		weekPlanID, c.day, c.meals, c.groups)
+	if err != nil {
+		return fmt.Errorf("failed to remove special day: %w", err)
+	}
+	if special == nil {
+		return nil
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO special_days (id, week_plan_id, day, type, label, meals, groups)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, special.ID, special.WeekPlanID, special.Day, special.Type, special.Label, special.Meals, special.Groups)
+	if err != nil {
+		return fmt.Errorf("failed to restore special day: %w", err)
+	}
+	return nil
+}